@@ -0,0 +1,32 @@
+// Package inorder holds the sequences taken by the MockOnInOrder_* helpers
+// of the testify mocks mockery generates with -inorder.
+package inorder
+
+import (
+	"sync"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// Sequence orders the expectations added to it, on one or more mocks: each
+// has to be met, as often as it is expected to be, before the calls
+// expected by the next are made. The zero value is an empty sequence.
+type Sequence struct {
+	mu   sync.Mutex
+	last *mock.Call
+}
+
+// Add makes call expected after the calls added before it, and returns it.
+// The order is checked by testify, which fails calls made too early as it
+// fails unexpected ones.
+func (s *Sequence) Add(call *mock.Call) *mock.Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.last != nil {
+		call.NotBefore(s.last)
+	}
+	s.last = call
+
+	return call
+}
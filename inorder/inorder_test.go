@@ -0,0 +1,68 @@
+package inorder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type File struct {
+	mock.Mock
+}
+
+func (m *File) Open(path string) error {
+	return m.Called(path).Error(0)
+}
+
+func (m *File) Read() int {
+	return m.Called().Int(0)
+}
+
+// recorder records the failures of a mock rather than failing the test.
+type recorder struct {
+	errors []string
+}
+
+func (r *recorder) Logf(string, ...interface{}) {}
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, format)
+}
+func (r *recorder) FailNow() {
+	panic(r)
+}
+
+func TestSequence(t *testing.T) {
+	var seq Sequence
+
+	file := &File{}
+	seq.Add(file.On("Open", "/foo").Return(nil))
+	seq.Add(file.On("Read").Return(3).Times(2))
+	seq.Add(file.On("Open", "/bar").Return(nil).Run(func(mock.Arguments) {}))
+
+	assert.NoError(t, file.Open("/foo"))
+	assert.Equal(t, 3, file.Read())
+	assert.Equal(t, 3, file.Read())
+	assert.NoError(t, file.Open("/bar"))
+
+	file.AssertExpectations(t)
+}
+
+func TestSequenceOutOfOrder(t *testing.T) {
+	var seq Sequence
+
+	rec := &recorder{}
+	file := &File{}
+	file.Test(rec)
+
+	seq.Add(file.On("Open", "/foo").Return(nil))
+	seq.Add(file.On("Read").Return(3))
+
+	assert.Panics(t, func() { file.Read() })
+	assert.Len(t, rec.errors, 1)
+
+	// The sequence goes on past the call out of order.
+	assert.NoError(t, file.Open("/foo"))
+	assert.Equal(t, 3, file.Read())
+	assert.Len(t, rec.errors, 1)
+}
@@ -1,15 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"go/scanner"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
+	"golang.org/x/tools/txtar"
+
 	"github.com/ryanbrainard/mockery/mockery"
 )
 
@@ -19,29 +27,176 @@ var fName = flag.String("name", "", "name or matching regular expression of inte
 var fPrint = flag.Bool("print", false, "print the generated mock to stdout")
 var fOutput = flag.String("output", "./mocks", "directory to write mocks to")
 var fDir = flag.String("dir", ".", "directory to search for interfaces")
+var fAnnotated = flag.Bool("annotated", false, "generates mocks for the interfaces annotated with //mockery:generate in all sub-directories")
 var fRecursive = flag.Bool("recursive", false, "recurse search into sub-directories")
 var fAll = flag.Bool("all", false, "generates mocks for all found interfaces in all sub-directories")
+var fExclude = flag.String("exclude", "", "comma-separated globs or /regular expressions/ of paths to skip while searching")
+var fIncludeTests = flag.Bool("include-tests", false, "search _test.go files for interfaces as well")
+var fVerbose = flag.Bool("v", false, "print more about what mockery does, such as files skipped while searching")
+var fQuiet = flag.Bool("quiet", false, "print nothing but errors")
+var fReport = flag.String("report", "", "print a report of the run to stdout in the given format: json")
+var fList = flag.Bool("list", false, "list the interfaces found and the state of their mocks instead of generating them")
+var fFormat = flag.String("format", "text", "format to print -list in: text or json")
+var fTags = flag.String("tags", "", "comma-separated list of build tags to select source files with")
+var fGOOS = flag.String("goos", "", "GOOS to select source files for, instead of the one mockery runs on")
+var fGOARCH = flag.String("goarch", "", "GOARCH to select source files for, instead of the one mockery runs on")
+var fStdin = flag.String("stdin", "", "read the source of the named file from stdin, such as an unsaved editor buffer, and print the mock of -name in it")
+var fImportPath = flag.String("import-path", "", "with -stdin, import path of the package of the file, instead of the one following from GOPATH")
 var fIP = flag.Bool("inpkg", false, "generate a mock that goes inside the original package")
+var fTestOnly = flag.Bool("testonly", false, "with -inpkg, write mocks to _test.go files so they are only compiled for tests")
+var fXTest = flag.Bool("xtest", false, "write mocks to _test.go files in the external test package next to the original package")
 var fCase = flag.String("case", "camel", "name the mocked file using casing convention")
 var fNote = flag.String("note", "", "comment to insert into prologue of each generated file")
+var fBackend = flag.String("backend", "testify", "style of mock to generate: testify, gomock, fake, or recorder to record calls to a real implementation")
+var fTemplate = flag.String("template", "", "text/template file to generate mocks with instead of a backend")
+var fExpandVariadic = flag.Bool("expand-variadic", false, "pass the elements of variadic arguments to the mock individually")
+var fLenient = flag.Bool("lenient", false, "generate mocks that can return zero values for unexpected calls")
+var fForce = flag.Bool("force", false, "overwrite files even if they were not generated by mockery")
+var fNoVerify = flag.Bool("no-verify", false, "write mocks even if they fail to type-check")
+var fBuildConstraint = flag.String("build-constraint", "", "build constraint for mocks instead of the one of their source file")
+var fNoBuildConstraint = flag.Bool("no-build-constraint", false, "write mocks without a build constraint")
+var fLayout = flag.String("layout", "flat", "where to write mocks: flat, mirror or colocated")
+var fAggregate = flag.Bool("aggregate", false, "write the mocks of all interfaces in a package to a single file")
+var fInOrder = flag.Bool("inorder", false, "generate helpers expecting calls in the order of a shared sequence")
+
+var fDryRun = flag.Bool("dry-run", false, "print the files a run would create or update without writing anything")
+var fDiff = flag.Bool("diff", false, "with -dry-run, also print a diff of each file that would change")
+
+var fNoCache = flag.Bool("no-cache", false, "generate every mock, even if it is cached as up to date")
+var fCacheDir = flag.String("cache-dir", "", "directory to cache mocks in, instead of mockery in the user cache directory")
+
+var fRemove = flag.Bool("remove", false, "with annotate, remove the //go:generate directives instead of adding them")
+
+var backend mockery.Backend
+
+// template is the text of -template, if any.
+var template string
+
+// cache remembers the mocks written before, and is nil with -no-cache or
+// when nothing is written.
+var cache *mockery.Cache
+
+// report collects what mockery did for -report, and is nil otherwise.
+var report *mockery.Report
+
+// buildContext selects source files for -goos, -goarch and -tags.
+var buildContext build.Context
+
+// Levels of verbosity, set by -quiet and -v.
+const (
+	quiet = iota
+	normal
+	verbose
+)
+
+var verbosity = normal
+
+// out receives progress messages, which move to stderr when stdout holds
+// the report.
+var out io.Writer = os.Stdout
+
+// logf prints a progress message if verbosity is at least level.
+func logf(level int, format string, args ...interface{}) {
+	if verbosity >= level {
+		fmt.Fprintf(out, format, args...)
+	}
+}
+
+// Exit codes, telling apart why a run failed. Flags the flag package
+// rejects exit with 2.
+const (
+	exitUsage    = 1 // invalid flags, or another error stopped the run
+	exitNoMatch  = 3 // no interface was found
+	exitGenerate = 4 // mocks could not be generated or written
+	exitParse    = 5 // files could not be read or parsed
+	exitStale    = 6 // -dry-run would change files
+)
+
+// failure is an error the run went on past.
+type failure struct {
+	code int
+	msg  string
+}
+
+// failures are printed together when the run ends.
+var failures []failure
+
+// stale records that -dry-run found files to change.
+var stale bool
+
+// failf records a failure, exiting with code in the end.
+func failf(code int, format string, args ...interface{}) {
+	failures = append(failures, failure{code, fmt.Sprintf(format, args...)})
+}
+
+// fatalf records a failure and exits with code right away.
+func fatalf(code int, format string, args ...interface{}) {
+	failf(code, format, args...)
+	exit(code)
+}
+
+// exit prints the failures recorded, writes the report, if any, and exits
+// with code. A zero code is replaced by the lowest code of the failures,
+// or exitStale if -dry-run found files to change.
+func exit(code int) {
+	if len(failures) == 1 {
+		fmt.Fprintln(os.Stderr, "1 error:")
+	} else if len(failures) > 1 {
+		fmt.Fprintf(os.Stderr, "%d errors:\n", len(failures))
+	}
+
+	lowest := 0
+	for _, f := range failures {
+		fmt.Fprintf(os.Stderr, "  %s\n", f.msg)
+		if lowest == 0 || f.code < lowest {
+			lowest = f.code
+		}
+	}
+
+	if code == 0 {
+		code = lowest
+	}
+	if code == 0 && stale {
+		code = exitStale
+	}
+
+	if report != nil {
+		report.Relative(absPath("."))
+		report.WriteJSON(os.Stdout)
+	}
+
+	os.Exit(code)
+}
 
 func main() {
 	flag.Parse()
 
+	// mockery annotate takes the same flags, on either side of it, but
+	// adds //go:generate directives for the interfaces found rather than
+	// mocking them.
+	args := flag.Args()
+	annotate := len(args) > 0 && args[0] == "annotate"
+	if annotate {
+		flag.CommandLine.Parse(args[1:])
+		args = flag.Args()
+	}
+	if len(args) > 0 {
+		fatalf(exitUsage, "Unexpected argument %s", args[0])
+	}
+
 	var recursive bool
 	var filter *regexp.Regexp
 	var err error
 	var limitOne bool
+	var goGenerate bool
 
 	if *fName != "" && *fAll {
-		fmt.Fprintln(os.Stderr, "Specify -name or -all, but not both")
-		os.Exit(1)
+		fatalf(exitUsage, "Specify -name or -all, but not both")
 	} else if *fName != "" {
 		recursive = *fRecursive
 		if strings.ContainsAny(*fName, regexMetadataChars) {
 			if filter, err = regexp.Compile(*fName); err != nil {
-				fmt.Fprintln(os.Stderr, "Invalid regular expression provided to -name")
-				os.Exit(1)
+				fatalf(exitUsage, "Invalid regular expression provided to -name")
 			}
 		} else {
 			filter = regexp.MustCompile(fmt.Sprintf("^%s$", *fName))
@@ -50,131 +205,781 @@ func main() {
 	} else if *fAll {
 		recursive = true
 		filter = regexp.MustCompile(".*")
+	} else if *fAnnotated {
+		recursive = true
+	} else if *fList {
+		// Listing is meant to find out what to mock in the first place.
+		recursive = true
+		filter = regexp.MustCompile(".*")
+	} else if !annotate && os.Getenv("GOFILE") != "" && os.Getenv("GOLINE") != "" {
+		// Run by go generate, in the directory of the package.
+		goGenerate = true
 	} else {
-		fmt.Fprintln(os.Stderr, "Use -name to specify the name of the interface or -all for all interfaces found")
-		os.Exit(1)
+		fatalf(exitUsage, "Use -name to specify the name of the interface, -all for all interfaces found or -annotated for those annotated with //mockery:generate")
 	}
 
-	generated := walkDir(*fDir, recursive, filter, limitOne)
+	if *fStdin != "" {
+		if *fName == "" {
+			fatalf(exitUsage, "-stdin requires -name")
+		} else if *fList || *fDryRun || *fAggregate || annotate {
+			fatalf(exitUsage, "-stdin can not be combined with -list, -dry-run, -aggregate or annotate")
+		}
 
-	if *fName != "" && !generated {
-		fmt.Printf("Unable to find %s in any go files under this path\n", *fName)
-		os.Exit(1)
+		// The mock is printed, as the buffer it is for is not saved yet.
+		*fPrint = true
+	} else if *fImportPath != "" {
+		fatalf(exitUsage, "-import-path requires -stdin")
 	}
-}
 
-func walkDir(dir string, recursive bool, filter *regexp.Regexp, limitOne bool) (generated bool) {
-	files, err := ioutil.ReadDir(dir)
-	if err != nil {
-		return
+	if *fQuiet && *fVerbose {
+		fatalf(exitUsage, "Specify -quiet or -v, but not both")
+	} else if *fQuiet {
+		verbosity = quiet
+	} else if *fVerbose {
+		verbosity = verbose
 	}
 
-	for _, file := range files {
-		if strings.HasPrefix(file.Name(), ".") {
-			continue
+	if *fReport != "" {
+		if *fReport != "json" {
+			fatalf(exitUsage, "Unknown format provided to -report: %s", *fReport)
+		} else if *fPrint {
+			fatalf(exitUsage, "Specify -print or -report, but not both")
 		}
 
-		path := filepath.Join(dir, file.Name())
+		report = mockery.NewReport()
+		out = os.Stderr
+	}
 
-		if file.IsDir() {
-			if recursive {
-				generated = walkDir(path, recursive, filter, limitOne) || generated
-				if generated && limitOne {
-					return
-				}
-			}
-			continue
+	if *fList {
+		if *fFormat != "text" && *fFormat != "json" {
+			fatalf(exitUsage, "Unknown format provided to -format: %s", *fFormat)
+		} else if *fPrint || report != nil || annotate {
+			fatalf(exitUsage, "-list can not be combined with -print, -report or annotate")
+		}
+	}
+
+	if *fDryRun && (*fPrint || report != nil || *fList) {
+		fatalf(exitUsage, "-dry-run can not be combined with -print, -report or -list")
+	} else if *fDiff && !*fDryRun {
+		fatalf(exitUsage, "-diff requires -dry-run")
+	}
+
+	if *fAnnotated && (*fName != "" || *fAll) {
+		fatalf(exitUsage, "Specify -annotated or -name/-all, but not both")
+	}
+
+	if *fTemplate != "" {
+		if isFlagSet("backend") {
+			fatalf(exitUsage, "Specify -backend or -template, but not both")
+		}
+
+		text, err := ioutil.ReadFile(*fTemplate)
+		if err != nil {
+			fatalf(exitUsage, "Unable to read template: %s", err)
+		}
+
+		template = string(text)
+		if backend, err = mockery.NewTemplateBackend(template); err != nil {
+			fatalf(exitUsage, "Unable to parse template: %s", err)
+		}
+	} else if (*fLenient || *fInOrder) && *fBackend != "testify" {
+		fatalf(exitUsage, "-lenient and -inorder are only supported by the testify backend")
+	} else if b, ok := mockery.Backends[*fBackend]; ok {
+		backend = b
+	} else {
+		fatalf(exitUsage, "Unknown backend provided to -backend: %s", *fBackend)
+	}
+
+	// Source files are selected as the go command would for -goos and
+	// -goarch, which mocks are verified against as well.
+	buildContext = build.Default
+	buildContext.BuildTags = strings.FieldsFunc(*fTags, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if *fGOOS != "" {
+		buildContext.GOOS = *fGOOS
+	}
+	if *fGOARCH != "" {
+		buildContext.GOARCH = *fGOARCH
+	}
+	if buildContext.GOOS != build.Default.GOOS || buildContext.GOARCH != build.Default.GOARCH {
+		// As for the go command, cgo is off when cross-compiling unless
+		// enabled explicitly.
+		buildContext.CgoEnabled = os.Getenv("CGO_ENABLED") == "1"
+	}
+
+	if *fBuildConstraint != "" {
+		if *fNoBuildConstraint {
+			fatalf(exitUsage, "Specify -build-constraint or -no-build-constraint, but not both")
 		}
 
-		if !strings.HasSuffix(path, ".go") {
+		if _, err := constraint.Parse("//go:build " + *fBuildConstraint); err != nil {
+			fatalf(exitUsage, "Invalid expression provided to -build-constraint: %s", err)
+		}
+	}
+
+	if *fLayout != mockery.LayoutFlat && *fLayout != mockery.LayoutMirror && *fLayout != mockery.LayoutColocated {
+		fatalf(exitUsage, "Unknown layout provided to -layout: %s", *fLayout)
+	} else if *fLayout != mockery.LayoutFlat && (*fIP || *fXTest) {
+		fatalf(exitUsage, "Specify -layout or -inpkg/-xtest, but not both")
+	} else if *fXTest && *fIP {
+		fatalf(exitUsage, "Specify -inpkg or -xtest, but not both")
+	} else if *fTestOnly && !*fIP {
+		fatalf(exitUsage, "-testonly requires -inpkg, use -xtest for the external test package")
+	}
+
+	if !*fNoCache && !*fPrint && !*fDryRun && !*fList && !annotate {
+		dir := *fCacheDir
+		if dir == "" {
+			dir, err = mockery.DefaultCacheDir()
+		}
+
+		if err == nil {
+			cache = &mockery.Cache{Dir: dir}
+		} else {
+			logf(verbose, "Not caching mocks: %s\n", err)
+		}
+	}
+
+	var ifaces []*mockery.Interface
+	if *fStdin != "" {
+		ifaces = stdinInterfaces(filter)
+	} else if goGenerate {
+		ifaces = []*mockery.Interface{goGenerateInterface()}
+	} else {
+		ifaces = walk(recursive, filter, limitOne)
+	}
+
+	if report != nil {
+		for _, iface := range ifaces {
+			report.AddInterface(iface)
+		}
+	}
+
+	if len(ifaces) == 0 {
+		if *fStdin != "" {
+			fatalf(exitNoMatch, "Unable to find %s in the source of %s read from stdin", *fName, *fStdin)
+		} else if *fName != "" {
+			fatalf(exitNoMatch, "Unable to find %s in any go files under this path", *fName)
+		} else if *fAnnotated {
+			fatalf(exitNoMatch, "Unable to find interfaces annotated with //mockery:generate in any go files under this path")
+		}
+		fatalf(exitNoMatch, "Unable to find interfaces in any go files under this path")
+	}
+
+	if annotate {
+		annotateFiles(ifaces)
+		exit(0)
+	}
+
+	files := fileNamer().Plan(ifaces)
+
+	if *fList {
+		listFiles(files)
+		exit(0)
+	}
+
+	// Files clashing with others are left out, and the rest generated.
+	var planned []*mockery.MockFile
+	for _, file := range files {
+		if file.Err != nil {
+			failf(exitGenerate, "Unable to generate mock for %s in %s: %s",
+				file.Names(), relPath(filepath.Dir(file.Interfaces[0].Path)), file.Err)
+			if report != nil {
+				report.AddError(file.Interfaces, file.Err)
+			}
 			continue
 		}
+		planned = append(planned, file)
+	}
+	files = planned
 
-		p := mockery.NewParser()
+	if *fPrint && len(files) > 1 {
+		printArchive(files)
+		exit(0)
+	}
+
+	for _, file := range files {
+		genMock(file)
+	}
 
-		err = p.Parse(path)
+	if cache != nil {
+		err = cache.Evict(mockery.CacheMaxAge)
 		if err != nil {
-			continue
+			logf(verbose, "Unable to evict old cache entries: %s\n", err)
 		}
+	}
 
-		for _, iface := range p.Interfaces() {
-			if !filter.MatchString(iface.Name) {
-				continue
-			}
-			genMock(iface)
-			generated = true
-			if limitOne {
-				return
-			}
+	exit(0)
+}
+
+// walk finds the interfaces to mock under -dir.
+func walk(recursive bool, filter *regexp.Regexp, limitOne bool) []*mockery.Interface {
+	walker := &mockery.Walker{
+		Recursive:    recursive,
+		Filter:       filter,
+		Annotated:    *fAnnotated,
+		LimitOne:     limitOne,
+		IncludeTests: *fIncludeTests,
+		Context:      &buildContext,
+	}
+
+	if *fExclude != "" {
+		walker.Exclude = strings.Split(*fExclude, ",")
+	}
+
+	if !*fIP && !*fXTest {
+		walker.SkipDirs = []string{*fOutput}
+	}
+
+	walker.Skipped = func(path, reason string) {
+		logf(verbose, "Skipping %s: %s\n", relPath(path), reason)
+		if report != nil {
+			report.Files = append(report.Files, mockery.ReportFile{Path: path, Skipped: reason})
+		}
+	}
+
+	walker.SkippedInterface = func(iface *mockery.Interface, reason string) {
+		logf(verbose, "Skipping %s in %s: %s\n", iface.Name, relPath(iface.Path), reason)
+	}
+
+	walker.Failed = func(path string, err error) {
+		if _, ok := err.(scanner.ErrorList); ok {
+			failf(exitParse, "%s", err)
+		} else {
+			failf(exitParse, "Unable to read %s: %s", relPath(path), err)
+		}
+
+		if report != nil {
+			report.Files = append(report.Files, mockery.ReportFile{Path: path, Skipped: err.Error()})
+			report.AddFileError(path, err)
 		}
 	}
 
-	return
+	walker.Parsed = func(path string) {
+		logf(verbose, "Searching %s\n", relPath(path))
+		if report != nil {
+			report.Files = append(report.Files, mockery.ReportFile{Path: path})
+		}
+	}
+
+	ifaces, err := walker.Walk(*fDir)
+	if err != nil {
+		fatalf(exitUsage, "Unable to search %s: %s", *fDir, err)
+	}
+
+	return ifaces
 }
 
-func genMock(iface *mockery.Interface) {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Printf("Unable to generated mock for '%s': %s\n", iface.Name, r)
-			return
+// stdinInterfaces returns the interfaces matching filter declared in the
+// source read from stdin, which stands for the file named by -stdin.
+func stdinInterfaces(filter *regexp.Regexp) []*mockery.Interface {
+	src, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		fatalf(exitUsage, "Unable to read stdin: %s", err)
+	}
+
+	p := mockery.NewParser()
+
+	err = p.ParseSource(*fStdin, src)
+	if err != nil {
+		fatalf(exitParse, "Unable to parse %s: %s", *fStdin, err)
+	}
+
+	var ifaces []*mockery.Interface
+	for _, iface := range p.Interfaces() {
+		if filter.MatchString(iface.Name) {
+			ifaces = append(ifaces, iface)
 		}
-	}()
+	}
 
-	var out io.Writer
+	return ifaces
+}
+
+// goGenerateInterface finds the interface declared after the
+// //go:generate directive running mockery, as described by the
+// environment go generate sets up.
+func goGenerateInterface() *mockery.Interface {
+	path := filepath.Join(*fDir, os.Getenv("GOFILE"))
 
-	pkg := "mocks"
-	name := iface.Name
-	caseName := iface.Name
-	if *fCase == "underscore" {
-		rxp := regexp.MustCompile("(.)([A-Z])")
-		caseName = strings.ToLower(rxp.ReplaceAllString(caseName, "$1_$2"))
+	line, err := strconv.Atoi(os.Getenv("GOLINE"))
+	if err != nil {
+		fatalf(exitUsage, "Invalid GOLINE: %s", os.Getenv("GOLINE"))
 	}
 
-	if *fPrint {
-		out = os.Stdout
-	} else {
-		var path string
+	p := mockery.NewParser()
 
-		if *fIP {
-			path = filepath.Join(filepath.Dir(iface.Path), "mock_"+caseName+".go")
-		} else {
-			path = filepath.Join(*fOutput, caseName+".go")
-			os.MkdirAll(filepath.Dir(path), 0755)
-			pkg = filepath.Base(filepath.Dir(path))
+	err = p.Parse(path)
+	if err != nil {
+		fatalf(exitParse, "Unable to parse %s: %s", path, err)
+	}
+
+	iface, err := p.FindAfter(line)
+	if err != nil {
+		fatalf(exitNoMatch, "Unable to mock the type declared after %s:%d: %s", path, line, err)
+	} else if iface == nil {
+		fatalf(exitNoMatch, "No interface declared after %s:%d, use -name or -all to select one", path, line)
+	}
+
+	if pkg := os.Getenv("GOPACKAGE"); pkg != "" && pkg != iface.File.Name.Name {
+		fatalf(exitUsage, "%s declares package %s, not GOPACKAGE %s", path, iface.File.Name.Name, pkg)
+	}
+
+	if report != nil {
+		report.Files = append(report.Files, mockery.ReportFile{Path: iface.Path})
+	}
+
+	return iface
+}
+
+// fileNamer returns the namer of mock files configured by the flags.
+func fileNamer() *mockery.FileNamer {
+	return &mockery.FileNamer{
+		Output:     *fOutput,
+		Root:       *fDir,
+		Layout:     *fLayout,
+		InPackage:  *fIP,
+		Group:      *fPrint,
+		Aggregate:  *fAggregate,
+		TestOnly:   *fTestOnly,
+		XTest:      *fXTest,
+		Underscore: *fCase == "underscore",
+		Printed:    *fPrint,
+	}
+}
+
+// genMock generates and writes the mocks of file, recording a failure
+// rather than stopping the run if it can not.
+func genMock(file *mockery.MockFile) {
+	name := file.Names()
+
+	if !*fPrint {
+		if !*fDryRun {
+			os.MkdirAll(filepath.Dir(file.Path), 0755)
 		}
+	}
 
-		f, err := os.Create(path)
+	var key string
+	if cache != nil {
+		var err error
+		key, err = cache.Key(file.Interfaces, cacheSettings(file)...)
 		if err != nil {
-			fmt.Printf("Unable to create output file for generated mock: %s\n", err)
-			os.Exit(1)
+			logf(verbose, "Not caching %s: %s\n", relPath(file.Path), err)
+		} else if cache.Fresh(key, file.Path) {
+			logf(verbose, "Cached %s\n", relPath(file.Path))
+			reportOutput(file.Path, mockery.OutputUnchanged, file.Interfaces)
+			return
 		}
+	}
 
-		defer f.Close()
+	if !*fPrint && !*fDryRun {
+		for _, iface := range file.Interfaces {
+			logf(normal, "Generating mock for: %s\n", iface.Name)
+		}
+	}
 
-		out = f
+	src, err := renderMock(file)
+	if err != nil {
+		failf(exitGenerate, "Error with %s: %s", name, err)
+		if report != nil {
+			report.AddError(file.Interfaces, err)
+		}
+		return
+	}
 
-		fmt.Printf("Generating mock for: %s\n", name)
+	err = writeFile(file.Path, src, file.Interfaces)
+	if err != nil {
+		failf(exitGenerate, "Error writing %s: %s", name, err)
+		if report != nil {
+			report.AddError(file.Interfaces, err)
+		}
+		return
 	}
 
-	gen := mockery.NewGenerator(iface)
+	if key != "" {
+		err = cache.Put(key, src)
+		if err != nil {
+			logf(verbose, "Not caching %s: %s\n", relPath(file.Path), err)
+		}
+	}
+}
+
+// nonGenerateFlags change what a run does, but not the mocks it
+// generates, like searchFlags.
+var nonGenerateFlags = map[string]bool{
+	"quiet":     true,
+	"report":    true,
+	"force":     true,
+	"no-cache":  true,
+	"cache-dir": true,
+}
+
+// cacheSettings returns what shapes the mocks of file besides the sources
+// of its interfaces, keying them in the cache: where they go, the flags
+// given and the template, along with the mockery binary, which changes
+// when it is rebuilt, and the platform they are verified for.
+func cacheSettings(file *mockery.MockFile) []string {
+	settings := []string{
+		"path=" + absPath(file.Path),
+		"package=" + file.Package,
+		fmt.Sprintf("inpkg=%t", file.InPackage),
+		"template=" + template,
+		"platform=" + buildContext.GOOS + "/" + buildContext.GOARCH,
+		fmt.Sprintf("cgo=%t", buildContext.CgoEnabled),
+	}
+
+	flag.VisitAll(func(f *flag.Flag) {
+		if !searchFlags[f.Name] && !nonGenerateFlags[f.Name] {
+			settings = append(settings, f.Name+"="+f.Value.String())
+		}
+	})
+
+	if exe, err := os.Executable(); err == nil {
+		if info, err := os.Stat(exe); err == nil {
+			settings = append(settings, fmt.Sprintf("binary=%s %d %d", exe, info.Size(), info.ModTime().UnixNano()))
+		}
+	}
+
+	return settings
+}
+
+// renderMock generates and verifies the source of file. The generator
+// panics on source it can not mock, which is returned as an error.
+func renderMock(file *mockery.MockFile) (src []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			src, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+
+	gen := mockery.NewMultiGenerator(file.Interfaces)
+	gen.Backend = backend
+	gen.ExpandVariadic = *fExpandVariadic
+	gen.Lenient = *fLenient
+	gen.InOrder = *fInOrder
+	gen.BuildConstraint = *fBuildConstraint
+	gen.NoBuildConstraint = *fNoBuildConstraint
+	gen.ImportPath = *fImportPath
+	gen.Context = &buildContext
 
 	gen.GeneratePrologueNote(*fNote)
 
-	if *fIP {
+	if file.InPackage {
 		gen.GenerateIPPrologue()
 	} else {
-		gen.GeneratePrologue(pkg)
+		gen.GeneratePrologue(file.Package)
 	}
 
-	err := gen.Generate()
+	err = gen.Generate()
 	if err != nil {
-		fmt.Printf("Error with %s: %s\n", name, err)
-		os.Exit(1)
+		return nil, err
 	}
 
-	err = gen.Write(out)
+	src, err = gen.Bytes()
 	if err != nil {
-		fmt.Printf("Error writing %s: %s\n", name, err)
-		os.Exit(1)
+		return nil, err
+	}
+
+	// The source read with -stdin may differ from the package on disk,
+	// which a mock of it can not be checked against.
+	if *fStdin != "" {
+		return src, nil
 	}
+
+	err = gen.Verify(file.Path, src)
+	if err != nil {
+		if !*fNoVerify {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Writing %s anyway: %s\n", file.Names(), err)
+	}
+
+	return src, nil
+}
+
+// listFiles prints the interfaces mocked in files, along with whether
+// their mocks are up to date, in the format given by -format. The mocks of
+// files clashing with others are listed as failing. Nothing is written.
+func listFiles(files []*mockery.MockFile) {
+	listings := mockery.Listings{}
+
+	for _, file := range files {
+		status, err := mockery.MockFailing, file.Err
+		if err == nil {
+			status, err = mockStatus(file)
+		}
+
+		for _, iface := range file.Interfaces {
+			pkg, perr := iface.ImportPath()
+			if perr != nil || strings.HasPrefix(pkg, "..") {
+				pkg = iface.File.Name.Name
+			}
+
+			l := mockery.Listing{
+				Package:  pkg,
+				Name:     iface.Name,
+				File:     relPath(iface.Path),
+				Line:     iface.Line,
+				Exported: ast.IsExported(iface.Name),
+				Methods:  iface.NumMethods(),
+				Mock:     relPath(file.Path),
+				Status:   status,
+			}
+			if err != nil {
+				l.Error = err.Error()
+			}
+
+			listings = append(listings, l)
+		}
+	}
+
+	var err error
+	if *fFormat == "json" {
+		err = listings.WriteJSON(os.Stdout)
+	} else {
+		err = listings.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fatalf(exitUsage, "%s", err)
+	}
+}
+
+// mockStatus reports whether the mocks of file are written and up to
+// date, by generating them again. The error is why they could not be
+// generated.
+func mockStatus(file *mockery.MockFile) (string, error) {
+	return mockery.MockStatus(file.Path, func() ([]byte, error) {
+		return renderMock(file)
+	})
+}
+
+// printArchive prints files, which can not be combined into a single Go
+// file, as a txtar archive holding each under its path.
+func printArchive(files []*mockery.MockFile) {
+	archive := &txtar.Archive{}
+
+	for _, file := range files {
+		src, err := renderMock(file)
+		if err != nil {
+			failf(exitGenerate, "Error with %s: %s", file.Names(), err)
+			if report != nil {
+				report.AddError(file.Interfaces, err)
+			}
+			continue
+		}
+
+		archive.Files = append(archive.Files, txtar.File{Name: relPath(file.Path), Data: src})
+	}
+
+	os.Stdout.Write(txtar.Format(archive))
+}
+
+func relPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+
+	return path
+}
+
+// writeFile writes src, the mocks of ifaces, to path, or to stdout with
+// -print. A file already holding src is left alone.
+func writeFile(path string, src []byte, ifaces []*mockery.Interface) error {
+	if *fPrint {
+		_, err := os.Stdout.Write(src)
+		return err
+	} else if *fDryRun {
+		return planWrite(path, src)
+	}
+
+	status := mockery.OutputWritten
+
+	existing, err := ioutil.ReadFile(path)
+	if err == nil && bytes.Equal(existing, src) {
+		status = mockery.OutputUnchanged
+		logf(verbose, "Unchanged %s\n", relPath(path))
+	} else {
+		err = mockery.WriteFile(path, src, *fForce)
+		if err == mockery.ErrNotGenerated {
+			status = mockery.OutputSkipped
+			err = fmt.Errorf("refusing to overwrite %s: %s (use -force to overwrite it)", path, err)
+		} else if err == nil {
+			logf(verbose, "Writing %s\n", relPath(path))
+		}
+	}
+
+	if err == nil || status == mockery.OutputSkipped {
+		reportOutput(path, status, ifaces)
+	}
+
+	return err
+}
+
+// reportOutput records the file at path, holding the mocks of ifaces, in
+// the report, if any.
+func reportOutput(path, status string, ifaces []*mockery.Interface) {
+	if report == nil {
+		return
+	}
+
+	var names []string
+	for _, iface := range ifaces {
+		names = append(names, iface.Name)
+	}
+	report.Outputs = append(report.Outputs, mockery.ReportOutput{Path: path, Status: status, Interfaces: names})
+}
+
+// planWrite prints what writeFile would do with src for -dry-run, along
+// with a diff of the change with -diff. It fails where writeFile would.
+func planWrite(path string, src []byte) error {
+	var op string
+
+	existing, err := ioutil.ReadFile(path)
+	switch {
+	case err != nil:
+		op = "create"
+	case bytes.Equal(existing, src):
+		op = "unchanged"
+	case !*fForce && !mockery.IsGenerated(existing):
+		return fmt.Errorf("refusing to overwrite %s: %s (use -force to overwrite it)", path, mockery.ErrNotGenerated)
+	default:
+		op = "update"
+	}
+
+	if op != "unchanged" {
+		stale = true
+	}
+
+	printPlan(op, path, existing, src)
+	return nil
+}
+
+// printPlan prints op, the change of the file at path from old to new,
+// for -dry-run.
+func printPlan(op, path string, old, new []byte) {
+	fmt.Printf("%-9s %s\n", op, relPath(path))
+
+	if !*fDiff {
+		return
+	}
+
+	oldName, newName := path, path
+	if rel := relPath(path); !filepath.IsAbs(rel) {
+		oldName, newName = "a/"+filepath.ToSlash(rel), "b/"+filepath.ToSlash(rel)
+	}
+	if old == nil {
+		oldName = "/dev/null"
+	}
+
+	os.Stdout.Write(mockery.Diff(oldName, newName, old, new))
+}
+
+// annotateFiles adds a //go:generate directive running mockery with the
+// flags given above each of ifaces, or removes them with -remove.
+func annotateFiles(ifaces []*mockery.Interface) {
+	var paths []string
+	names := map[string][]string{}
+
+	for _, iface := range ifaces {
+		if _, ok := names[iface.Path]; !ok {
+			paths = append(paths, iface.Path)
+		}
+		names[iface.Path] = append(names[iface.Path], iface.Name)
+	}
+
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			failf(exitParse, "Unable to annotate %s: %s", relPath(path), err)
+			continue
+		}
+
+		command := ""
+		if !*fRemove {
+			command = generateCommand(filepath.Dir(path))
+		}
+
+		out, err := mockery.Annotate(src, names[path], command)
+		if err != nil {
+			failf(exitParse, "Unable to annotate %s: %s", relPath(path), err)
+			continue
+		}
+
+		if *fPrint {
+			os.Stdout.Write(out)
+			continue
+		}
+
+		if bytes.Equal(src, out) {
+			continue
+		}
+
+		logf(normal, "Annotating %s in %s\n", strings.Join(names[path], ", "), relPath(path))
+
+		err = mockery.WriteFile(path, out, true)
+		if err != nil {
+			failf(exitGenerate, "Unable to annotate %s: %s", relPath(path), err)
+		}
+	}
+}
+
+// searchFlags only select interfaces, which go generate does by the
+// position of the directive instead.
+var searchFlags = map[string]bool{
+	"name":          true,
+	"all":           true,
+	"annotated":     true,
+	"recursive":     true,
+	"dir":           true,
+	"exclude":       true,
+	"include-tests": true,
+	"v":             true,
+	"print":         true,
+	"remove":        true,
+	"output":        true,
+	"aggregate":     true,
+	"stdin":         true,
+	"import-path":   true,
+	"dry-run":       true,
+	"diff":          true,
+	"list":          true,
+	"format":        true,
+}
+
+// isFlagSet reports whether the flag called name was given on the command
+// line, even if to its default value.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+// generateCommand returns the mockery command for a //go:generate
+// directive in dir, repeating the flags given that control how mocks are
+// generated. -aggregate is left out, as each directive mocks a single
+// interface.
+func generateCommand(dir string) string {
+	var flags []*flag.Flag
+	flag.Visit(func(f *flag.Flag) {
+		if !searchFlags[f.Name] {
+			flags = append(flags, f)
+		}
+	})
+
+	return fileNamer().GenerateCommand(dir, flags)
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return path
 }
@@ -0,0 +1,136 @@
+package mockery
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+)
+
+// GeneratePrefix starts the directives go generate runs.
+const GeneratePrefix = "//go:generate "
+
+// Annotate returns src with a //go:generate directive running command
+// right above the declarations of the interfaces named in names. Earlier
+// directives running mockery in the doc comments of those declarations are
+// replaced. With an empty command, they are only removed.
+//
+// go generate only sees directives at the start of a line, so interfaces
+// declared in a parenthesized group get theirs above the group, naming the
+// interface with -name.
+func Annotate(src []byte, names []string, command string) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	lines := strings.SplitAfter(string(src), "\n")
+
+	// Edits are applied from the bottom up, so the line numbers of the
+	// ones still to come stay valid.
+	type edit struct {
+		line   int
+		order  int
+		remove bool
+		text   string
+	}
+	var edits []edit
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			typespec := spec.(*ast.TypeSpec)
+			if _, ok := typespec.Type.(*ast.InterfaceType); !ok || !wanted[typespec.Name.Name] {
+				continue
+			}
+
+			name := ""
+			if gen.Lparen.IsValid() {
+				name = typespec.Name.Name
+			}
+
+			if gen.Doc != nil {
+				for _, c := range gen.Doc.List {
+					if isMockeryDirective(c.Text) && (name == "" || directiveName(c.Text) == name) {
+						edits = append(edits, edit{line: fset.Position(c.Pos()).Line, remove: true})
+					}
+				}
+			}
+
+			if command != "" {
+				text := GeneratePrefix + command
+				if name != "" {
+					text += " -name " + name
+				}
+				edits = append(edits, edit{line: fset.Position(gen.Pos()).Line, order: len(edits), text: text + "\n"})
+			}
+		}
+	}
+
+	// Directives inserted on the same line keep the order of the
+	// declarations.
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].line != edits[j].line {
+			return edits[i].line > edits[j].line
+		}
+		return edits[i].order > edits[j].order
+	})
+
+	for _, e := range edits {
+		idx := e.line - 1
+		if e.remove {
+			lines = append(lines[:idx], lines[idx+1:]...)
+		} else {
+			lines = append(lines[:idx], append([]string{e.text}, lines[idx:]...)...)
+		}
+	}
+
+	return format.Source([]byte(strings.Join(lines, "")))
+}
+
+// isMockeryDirective reports whether the comment text is a //go:generate
+// directive running mockery.
+func isMockeryDirective(text string) bool {
+	if !strings.HasPrefix(text, GeneratePrefix) {
+		return false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(text, GeneratePrefix))
+	return len(fields) > 0 && path.Base(fields[0]) == "mockery"
+}
+
+// directiveName returns the value of the -name flag in the directive text,
+// if any.
+func directiveName(text string) string {
+	fields := strings.Fields(text)
+
+	for i, field := range fields {
+		if !strings.HasPrefix(field, "-") {
+			continue
+		}
+
+		field = strings.TrimLeft(field, "-")
+		if field == "name" && i+1 < len(fields) {
+			return fields[i+1]
+		}
+		if strings.HasPrefix(field, "name=") {
+			return strings.TrimPrefix(field, "name=")
+		}
+	}
+
+	return ""
+}
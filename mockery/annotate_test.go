@@ -0,0 +1,82 @@
+package mockery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const annotateSource = `package test
+
+// Requester does requests.
+type Requester interface {
+	Get(path string) (string, error)
+}
+
+type (
+	// Grouped is declared in a group.
+	Grouped interface {
+		Do()
+	}
+
+	Other interface {
+		Do()
+	}
+)
+
+type NotMocked interface {
+	Do()
+}
+`
+
+func TestAnnotate(t *testing.T) {
+	out, err := Annotate([]byte(annotateSource), []string{"Requester", "Grouped", "Other"}, "mockery -inpkg")
+	assert.NoError(t, err)
+
+	expected := `package test
+
+// Requester does requests.
+//
+//go:generate mockery -inpkg
+type Requester interface {
+	Get(path string) (string, error)
+}
+
+//go:generate mockery -inpkg -name Grouped
+//go:generate mockery -inpkg -name Other
+type (
+	// Grouped is declared in a group.
+	Grouped interface {
+		Do()
+	}
+
+	Other interface {
+		Do()
+	}
+)
+
+type NotMocked interface {
+	Do()
+}
+`
+
+	assert.Equal(t, expected, string(out))
+
+	out, err = Annotate(out, []string{"Requester", "Other"}, "mockery -lenient")
+	assert.NoError(t, err)
+
+	assert.Contains(t, string(out), "//\n//go:generate mockery -lenient\ntype Requester interface {")
+	assert.Contains(t, string(out), "//go:generate mockery -inpkg -name Grouped\n//go:generate mockery -lenient -name Other\ntype (")
+	assert.NotContains(t, string(out), "-inpkg -name Other")
+	assert.NotContains(t, string(out), "mockery -inpkg\n")
+
+	out, err = Annotate(out, []string{"Requester", "Grouped", "Other"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, annotateSource, string(out))
+}
+
+func TestDirectiveName(t *testing.T) {
+	assert.Equal(t, "Foo", directiveName("//go:generate mockery -name Foo -inpkg"))
+	assert.Equal(t, "Foo", directiveName("//go:generate mockery --name=Foo"))
+	assert.Equal(t, "", directiveName("//go:generate mockery -inpkg"))
+}
@@ -0,0 +1,27 @@
+package mockery
+
+// Backend renders the mock types for the interfaces of a Generator.
+type Backend interface {
+	// Imports lists the packages the mocks generated by g depend on.
+	Imports(g *Generator) []string
+
+	// Generate writes the mock type of iface, one of the interfaces of g,
+	// and its methods to g.
+	Generate(g *Generator, iface *Interface) error
+}
+
+// Backends holds the available backends by the name used to select them.
+var Backends = map[string]Backend{
+	"testify":  testifyBackend,
+	"gomock":   gomockBackend{},
+	"fake":     fakeBackend{},
+	"recorder": recorderBackend{},
+}
+
+func (g *Generator) backend() Backend {
+	if g.Backend == nil {
+		return testifyBackend
+	}
+
+	return g.Backend
+}
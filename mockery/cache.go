@@ -0,0 +1,184 @@
+package mockery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version is the version of mockery, which is part of every Cache key as
+// the mocks it generates change between versions.
+const Version = "0.2.0"
+
+// Cache remembers the mocks generated before, so that mocks of interfaces
+// whose sources and settings did not change need not be generated again.
+// It holds a file for each key, named after it, holding the hash of the
+// mock generated for it. Entries are touched whenever they are used, so
+// Evict can tell those no longer used.
+type Cache struct {
+	Dir string
+}
+
+// CacheMaxAge is how long mockery keeps cache entries that are not used.
+const CacheMaxAge = 30 * 24 * time.Hour
+
+// DefaultCacheDir returns the directory mockery caches mocks in by
+// default, under the cache directory of the user.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "mockery"), nil
+}
+
+// Key returns the key for the mocks of ifaces generated with settings,
+// which hold everything else shaping the mocks, such as the flags of the
+// run, the platform and the path they are written to. As mocks are
+// verified against the whole package, the key covers all Go files in the
+// directories declaring ifaces, other than those generated by mockery.
+//
+// Only those directories are covered, not the packages they import: a
+// change to a type from another package that an interface refers to, such
+// as an embedded interface, goes unnoticed until the directory of the
+// interface changes as well.
+func (c *Cache) Key(ifaces []*Interface, settings ...string) (string, error) {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "mockery %s\n", Version)
+	for _, setting := range settings {
+		fmt.Fprintf(h, "%q\n", setting)
+	}
+
+	seen := map[string]bool{}
+	for _, iface := range ifaces {
+		fmt.Fprintf(h, "interface %s\n", iface.Name)
+
+		dir := filepath.Dir(iface.Path)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		err := hashSources(h, dir)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashSources writes the names and contents of the Go files in dir to h.
+func hashSources(h io.Writer, dir string) error {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".go") {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		src, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+
+		if IsGenerated(src) {
+			continue
+		}
+
+		fmt.Fprintf(h, "file %s %d\n", name, len(src))
+		h.Write(src)
+	}
+
+	return nil
+}
+
+// Fresh reports whether the mocks for key were generated before and the
+// file at path still holds them.
+func (c *Cache) Fresh(key, path string) bool {
+	sum, err := ioutil.ReadFile(c.entry(key))
+	if err != nil {
+		return false
+	}
+
+	src, err := ioutil.ReadFile(path)
+	if err != nil || string(sum) != hashOf(src) {
+		return false
+	}
+
+	now := time.Now()
+	os.Chtimes(c.entry(key), now, now)
+
+	return true
+}
+
+// Put records src as the mocks generated for key.
+func (c *Cache) Put(key string, src []byte) error {
+	path := c.entry(key)
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, []byte(hashOf(src)), 0644)
+}
+
+// Evict removes the entries that were not used for longer than maxAge.
+func (c *Cache) Evict(maxAge time.Duration) error {
+	dirs, err := ioutil.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+
+		infos, err := ioutil.ReadDir(filepath.Join(c.Dir, dir.Name()))
+		if err != nil {
+			return err
+		}
+
+		for _, info := range infos {
+			if time.Since(info.ModTime()) > maxAge {
+				err = os.Remove(filepath.Join(c.Dir, dir.Name(), info.Name()))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// entry returns the path of the file for key, spreading them over
+// subdirectories named after the first byte of the key.
+func (c *Cache) entry(key string) string {
+	return filepath.Join(c.Dir, key[:2], key)
+}
+
+func hashOf(src []byte) string {
+	sum := sha256.Sum256(src)
+	return hex.EncodeToString(sum[:])
+}
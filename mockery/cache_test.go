@@ -0,0 +1,114 @@
+package mockery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheKey(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a/a.go":   "A",
+		"a/b.go":   "B",
+		"a/Gen.go": Header,
+	})
+	defer os.RemoveAll(root)
+
+	parser := NewParser()
+	err := parser.Parse(filepath.Join(root, "a", "a.go"))
+	assert.NoError(t, err)
+
+	iface, err := parser.Find("A")
+	assert.NoError(t, err)
+
+	cache := &Cache{Dir: filepath.Join(root, "cache")}
+
+	key, err := cache.Key([]*Interface{iface}, "-inpkg")
+	assert.NoError(t, err)
+
+	same, err := cache.Key([]*Interface{iface}, "-inpkg")
+	assert.NoError(t, err)
+	assert.Equal(t, key, same)
+
+	other, err := cache.Key([]*Interface{iface}, "-lenient")
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	// Mocks are left out, as writing them would change the key.
+	err = ioutil.WriteFile(filepath.Join(root, "a", "Gen.go"), []byte(Header+"\n\npackage a\n"), 0644)
+	assert.NoError(t, err)
+
+	same, err = cache.Key([]*Interface{iface}, "-inpkg")
+	assert.NoError(t, err)
+	assert.Equal(t, key, same)
+
+	err = ioutil.WriteFile(filepath.Join(root, "a", "b.go"), []byte("package a\n\ntype B interface{ Do() }\n"), 0644)
+	assert.NoError(t, err)
+
+	other, err = cache.Key([]*Interface{iface}, "-inpkg")
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestCacheFresh(t *testing.T) {
+	root := writeTree(t, map[string]string{})
+	defer os.RemoveAll(root)
+
+	cache := &Cache{Dir: filepath.Join(root, "cache")}
+	key := "0123456789abcdef"
+	path := filepath.Join(root, "A.go")
+	src := []byte(Header + "\n\npackage mocks\n")
+
+	assert.False(t, cache.Fresh(key, path))
+
+	err := cache.Put(key, src)
+	assert.NoError(t, err)
+	assert.False(t, cache.Fresh(key, path))
+
+	err = ioutil.WriteFile(path, src, 0644)
+	assert.NoError(t, err)
+	assert.True(t, cache.Fresh(key, path))
+
+	err = ioutil.WriteFile(path, append(src, "// edited\n"...), 0644)
+	assert.NoError(t, err)
+	assert.False(t, cache.Fresh(key, path))
+}
+
+func TestCacheEvict(t *testing.T) {
+	root := writeTree(t, map[string]string{})
+	defer os.RemoveAll(root)
+
+	cache := &Cache{Dir: filepath.Join(root, "cache")}
+	assert.NoError(t, cache.Evict(time.Hour))
+
+	src := []byte(Header + "\n\npackage mocks\n")
+	path := filepath.Join(root, "A.go")
+	err := ioutil.WriteFile(path, src, 0644)
+	assert.NoError(t, err)
+
+	for _, key := range []string{"00old", "00used", "01new"} {
+		err = cache.Put(key, src)
+		assert.NoError(t, err)
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	for _, key := range []string{"00old", "00used"} {
+		err = os.Chtimes(cache.entry(key), old, old)
+		assert.NoError(t, err)
+	}
+
+	// Using an entry keeps it.
+	assert.True(t, cache.Fresh("00used", path))
+
+	err = cache.Evict(time.Hour)
+	assert.NoError(t, err)
+
+	_, err = os.Stat(cache.entry("00old"))
+	assert.True(t, os.IsNotExist(err))
+	assert.True(t, cache.Fresh("00used", path))
+	assert.True(t, cache.Fresh("01new", path))
+}
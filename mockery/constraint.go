@@ -0,0 +1,139 @@
+package mockery
+
+import (
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+)
+
+// knownOS and knownArch are the values of GOOS and GOARCH the go command
+// recognizes in file names, as listed by go/build.
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+		"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+		"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+		"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+		"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+		"sparc": true, "sparc64": true, "wasm": true,
+	}
+	// unixOS are the systems satisfying the unix constraint.
+	unixOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "linux": true, "netbsd": true,
+		"openbsd": true, "solaris": true,
+	}
+)
+
+// fileNameConstraint returns the constraint implied by the name of the
+// file at path, such as linux for foo_linux.go or linux && amd64 for
+// foo_linux_amd64.go, or nil if it implies none.
+func fileNameConstraint(path string) constraint.Expr {
+	name := strings.TrimSuffix(filepath.Base(path), ".go")
+	name = strings.TrimSuffix(name, "_test")
+
+	// As for the go command, a suffix alone, as in linux.go, is the name of
+	// the file rather than a constraint.
+	idx := strings.Index(name, "_")
+	if idx < 0 {
+		return nil
+	}
+
+	parts := strings.Split(name[idx:], "_")
+	n := len(parts)
+
+	if n >= 2 && knownOS[parts[n-2]] && knownArch[parts[n-1]] {
+		return &constraint.AndExpr{X: &constraint.TagExpr{Tag: parts[n-2]}, Y: &constraint.TagExpr{Tag: parts[n-1]}}
+	}
+	if knownOS[parts[n-1]] || knownArch[parts[n-1]] {
+		return &constraint.TagExpr{Tag: parts[n-1]}
+	}
+
+	return nil
+}
+
+// andConstraint returns x && y, where either may be nil for no constraint.
+func andConstraint(x, y constraint.Expr) constraint.Expr {
+	if x == nil {
+		return y
+	}
+	if y == nil {
+		return x
+	}
+
+	return &constraint.AndExpr{X: x, Y: y}
+}
+
+// maxFreeTags bounds the tags other than systems and architectures
+// satisfiable tries all values of.
+const maxFreeTags = 12
+
+// satisfiable reports whether there is a build, with one GOOS and one
+// GOARCH, that expr holds for. Constraints on too many other tags to try
+// them all are assumed to hold.
+func satisfiable(expr constraint.Expr) bool {
+	oses, arches := []string{""}, []string{""}
+	var free []string
+
+	seen := map[string]bool{}
+	expr.Eval(func(tag string) bool {
+		if !seen[tag] {
+			seen[tag] = true
+			switch {
+			case knownOS[tag]:
+				oses = append(oses, tag)
+			case knownArch[tag]:
+				arches = append(arches, tag)
+			case tag != "unix":
+				free = append(free, tag)
+			}
+		}
+		return false
+	})
+
+	if len(free) > maxFreeTags {
+		return true
+	}
+
+	// Besides the systems named, "" stands for any other, which also
+	// decides whether it is a unix.
+	for _, goos := range oses {
+		for _, unix := range []bool{false, true} {
+			if goos != "" && unix != unixOS[goos] {
+				continue
+			}
+
+			for _, goarch := range arches {
+				for set := 0; set < 1<<uint(len(free)); set++ {
+					ok := expr.Eval(func(tag string) bool {
+						switch {
+						case knownOS[tag]:
+							return tag == goos
+						case knownArch[tag]:
+							return tag == goarch
+						case tag == "unix":
+							return unix
+						}
+
+						for idx, t := range free {
+							if t == tag {
+								return set&(1<<uint(idx)) != 0
+							}
+						}
+						return false
+					})
+					if ok {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+package mockery
+
+import (
+	"go/build/constraint"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileNameConstraint(t *testing.T) {
+	cases := []struct {
+		path, expr string
+	}{
+		{"/src/foo.go", ""},
+		{"/src/linux.go", ""},
+		{"/src/foo_linux.go", "linux"},
+		{"/src/foo_linux_test.go", "linux"},
+		{"/src/foo_amd64.go", "amd64"},
+		{"/src/foo_linux_amd64.go", "linux && amd64"},
+		{"/src/foo_amd64_linux.go", "linux"},
+		{"/src/foo_unix.go", ""},
+		{"/src/foo_bar.go", ""},
+	}
+
+	for _, c := range cases {
+		expr := fileNameConstraint(c.path)
+		if c.expr == "" {
+			assert.Nil(t, expr, c.path)
+		} else if assert.NotNil(t, expr, c.path) {
+			assert.Equal(t, c.expr, expr.String(), c.path)
+		}
+	}
+}
+
+func TestSatisfiable(t *testing.T) {
+	cases := []struct {
+		expr string
+		ok   bool
+	}{
+		{"linux", true},
+		{"linux && amd64", true},
+		{"linux && darwin", false},
+		{"(linux || darwin) && windows", false},
+		{"(linux || darwin) && darwin", true},
+		{"amd64 && arm64", false},
+		{"unix && windows", false},
+		{"unix && !linux", true},
+		{"!unix && solaris", false},
+		{"integration && !integration", false},
+		{"integration && linux", true},
+	}
+
+	for _, c := range cases {
+		expr, err := constraint.Parse("//go:build " + c.expr)
+		if assert.NoError(t, err) {
+			assert.Equal(t, c.ok, satisfiable(expr), c.expr)
+		}
+	}
+}
+
+func TestParseFileNameConstraint(t *testing.T) {
+	parser := NewParser()
+
+	err := parser.ParseSource("/src/store_windows.go", []byte("//go:build cgo\n\npackage store\n\ntype Store interface {\n\tGet() string\n}\n"))
+	assert.NoError(t, err)
+
+	iface, err := parser.Find("Store")
+	assert.NoError(t, err)
+	if assert.NotNil(t, iface.Constraint) {
+		assert.Equal(t, "cgo && windows", iface.Constraint.String())
+	}
+}
+
+func TestGeneratorDisjointConstraints(t *testing.T) {
+	parser := NewParser()
+
+	err := parser.ParseSource("/src/store_windows.go", []byte("package store\n\ntype Store interface {\n\tGet() string\n}\n"))
+	assert.NoError(t, err)
+	store, err := parser.Find("Store")
+	assert.NoError(t, err)
+
+	err = parser.ParseSource("/src/cache_linux.go", []byte("package store\n\ntype Cache interface {\n\tGet() string\n}\n"))
+	assert.NoError(t, err)
+	cache, err := parser.Find("Cache")
+	assert.NoError(t, err)
+
+	gen := NewMultiGenerator([]*Interface{store, cache})
+	err = gen.Generate()
+	assert.EqualError(t, err, "the build constraints of Store, Cache never hold together: windows && linux")
+
+	gen = NewMultiGenerator([]*Interface{store, cache})
+	gen.BuildConstraint = "windows || linux"
+	assert.NoError(t, gen.Generate())
+}
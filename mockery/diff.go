@@ -0,0 +1,190 @@
+package mockery
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// diffContext is the number of unchanged lines around each change in a
+// Diff.
+const diffContext = 3
+
+// diffLine is a line of a diff, kept (' '), removed ('-') or added ('+').
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// Diff returns a unified diff turning a into b, naming them oldName and
+// newName in its header, or nil if they are equal.
+func Diff(oldName, newName string, a, b []byte) []byte {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+
+	for start := 0; start < len(lines); {
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+
+		// A hunk takes in the changes that follow within twice the context,
+		// so hunks never overlap.
+		last := first
+		for i := first; i < len(lines) && i <= last+2*diffContext; i++ {
+			if lines[i].op != ' ' {
+				last = i
+			}
+		}
+
+		from, to := first-diffContext, last+diffContext+1
+		if from < start {
+			from = start
+		}
+		if to > len(lines) {
+			to = len(lines)
+		}
+
+		writeHunk(&buf, lines, from, to)
+		start = to
+	}
+
+	return buf.Bytes()
+}
+
+// writeHunk writes lines[from:to] as a hunk, numbering it by the lines
+// before it.
+func writeHunk(buf *bytes.Buffer, lines []diffLine, from, to int) {
+	oldStart, newStart := 1, 1
+	for _, l := range lines[:from] {
+		if l.op != '+' {
+			oldStart++
+		}
+		if l.op != '-' {
+			newStart++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, l := range lines[from:to] {
+		if l.op != '+' {
+			oldCount++
+		}
+		if l.op != '-' {
+			newCount++
+		}
+	}
+
+	// An empty range is numbered by the line before it.
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+
+	for _, l := range lines[from:to] {
+		buf.WriteByte(l.op)
+		buf.WriteString(l.text)
+		if len(l.text) == 0 || l.text[len(l.text)-1] != '\n' {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// diffLines returns the lines of a and b as a shortest edit turning a into
+// b, found by way of their longest common subsequence. Mocks mostly change
+// in a few places, so the lines a and b start and end with are set aside
+// first, leaving the quadratic search to the lines between.
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, line := range a[:prefix] {
+		lines = append(lines, diffLine{' ', line})
+	}
+	lines = append(lines, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', line})
+	}
+
+	return lines
+}
+
+// diffMiddle is diffLines for a and b neither starting nor ending with the
+// same line.
+func diffMiddle(a, b []string) []diffLine {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+
+	return lines
+}
+
+// splitLines splits src into lines, keeping their newlines.
+func splitLines(src []byte) []string {
+	var lines []string
+	for len(src) > 0 {
+		end := bytes.IndexByte(src, '\n') + 1
+		if end == 0 {
+			end = len(src)
+		}
+		lines = append(lines, string(src[:end]))
+		src = src[end:]
+	}
+	return lines
+}
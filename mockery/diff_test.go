@@ -0,0 +1,73 @@
+package mockery
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+
+	expected := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -9,3 +9,4 @@
+ i
+ j
+ k
++l
+`
+
+	assert.Equal(t, expected, string(Diff("old", "new", []byte(a), []byte(b))))
+	assert.Nil(t, Diff("old", "new", []byte(a), []byte(a)))
+}
+
+func TestDiffLarge(t *testing.T) {
+	// Too many lines to compare each of a with each of b.
+	var a, b strings.Builder
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&a, "%d\n", i)
+		if i == 50000 {
+			b.WriteString("changed\n")
+			continue
+		}
+		fmt.Fprintf(&b, "%d\n", i)
+	}
+
+	expected := `--- old
++++ new
+@@ -49998,7 +49998,7 @@
+ 49997
+ 49998
+ 49999
+-50000
++changed
+ 50001
+ 50002
+ 50003
+`
+
+	assert.Equal(t, expected, string(Diff("old", "new", []byte(a.String()), []byte(b.String()))))
+}
+
+func TestDiffCreate(t *testing.T) {
+	expected := `--- /dev/null
++++ new
+@@ -0,0 +1,2 @@
++a
++b
+\ No newline at end of file
+`
+
+	assert.Equal(t, expected, string(Diff("/dev/null", "new", nil, []byte("a\nb"))))
+}
@@ -0,0 +1,68 @@
+package mockery
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// DirectivePrefix starts the comments that tell mockery how to treat the
+// interface or method they document.
+const DirectivePrefix = "//mockery:"
+
+// Directives understood on interfaces and methods. Any other directive
+// is ignored.
+const (
+	// DirectiveGenerate selects an interface for -annotated.
+	DirectiveGenerate = "generate"
+
+	// DirectiveSkip leaves an interface out even if it matches -name or
+	// -all. On a method, it leaves the method out of the mock, which only
+	// declares it to panic when called.
+	DirectiveSkip = "skip"
+
+	// DirectiveName, as in //mockery:name=FakeStore, names the mock and
+	// its file.
+	DirectiveName = "name"
+
+	// DirectiveInPackage writes the mock into the package of the interface
+	// as -inpkg does.
+	DirectiveInPackage = "inpkg"
+)
+
+// Directives maps the names of the directives in a doc comment to their
+// values, which are empty for directives without one.
+type Directives map[string]string
+
+// ParseDirectives returns the directives in doc, which may be nil.
+func ParseDirectives(doc *ast.CommentGroup) Directives {
+	d := Directives{}
+	if doc == nil {
+		return d
+	}
+
+	for _, c := range doc.List {
+		if !strings.HasPrefix(c.Text, DirectivePrefix) {
+			continue
+		}
+
+		directive := strings.TrimSpace(strings.TrimPrefix(c.Text, DirectivePrefix))
+		if directive == "" {
+			continue
+		}
+
+		name, value := directive, ""
+		if idx := strings.Index(directive, "="); idx >= 0 {
+			name, value = strings.TrimSpace(directive[:idx]), strings.TrimSpace(directive[idx+1:])
+		}
+
+		d[name] = value
+	}
+
+	return d
+}
+
+// Has reports whether d holds the directive name.
+func (d Directives) Has(name string) bool {
+	_, ok := d[name]
+	return ok
+}
@@ -0,0 +1,106 @@
+package mockery
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// fakeBackend renders a fake without any dependency on a mocking library:
+// each method calls a function field set by the test and records its
+// arguments for later inspection.
+type fakeBackend struct{}
+
+func (fakeBackend) Imports(g *Generator) []string {
+	return []string{"sync"}
+}
+
+func (fakeBackend) Generate(g *Generator, iface *Interface) error {
+	name := g.mockName(iface)
+
+	type fakeMethod struct {
+		name   string
+		ftype  *ast.FuncType
+		record string
+	}
+
+	var methods []fakeMethod
+	for _, method := range g.methods(iface) {
+		ftype := method.Type.(*ast.FuncType)
+
+		fname := method.Names[0].Name
+		// The calls are recorded apart from the accessor returning them,
+		// which for an unexported method is named like lowerFirst would.
+		methods = append(methods, fakeMethod{fname, ftype, "calls" + upperFirst(fname)})
+	}
+
+	g.printf("type %s struct {\n", name)
+	for _, m := range methods {
+		_, _, params, _ := g.genList(iface, m.ftype.Params, true)
+		_, _, returns, _ := g.genList(iface, m.ftype.Results, false)
+		g.printf("\t%sFunc func(%s)%s\n", m.name, strings.Join(params, ", "), resultList(returns))
+	}
+	g.printf("\n\tmu sync.Mutex\n")
+	for _, m := range methods {
+		g.printf("\t%s []%s%sCall\n", m.record, name, m.name)
+	}
+	g.printf("}\n")
+
+	for _, m := range methods {
+		paramNames, paramTypes, params, args := g.genList(iface, m.ftype.Params, true)
+		_, _, returns, _ := g.genList(iface, m.ftype.Results, false)
+
+		call := name + m.name + "Call"
+
+		var fields, values []string
+		for idx, pname := range paramNames {
+			field := upperFirst(strings.TrimLeft(pname, "_"))
+			fields = append(fields, field+" "+strings.Replace(paramTypes[idx], "...", "[]", 1))
+			values = append(values, field+": "+pname)
+		}
+
+		g.printf("\n// %s holds the arguments of a call to %s.\n", call, m.name)
+		g.printf("type %s struct {\n", call)
+		for _, f := range fields {
+			g.printf("\t%s\n", f)
+		}
+		g.printf("}\n\n")
+
+		g.printf("func (m *%s) %s(%s)%s {\n", name, m.name, strings.Join(params, ", "), resultList(returns))
+		g.printf("\tm.mu.Lock()\n")
+		g.printf("\tm.%s = append(m.%s, %s{%s})\n", m.record, m.record, call, strings.Join(values, ", "))
+		g.printf("\tm.mu.Unlock()\n\n")
+		g.printf("\tif m.%sFunc == nil {\n", m.name)
+		g.printf("\t\tpanic(\"%s.%sFunc is nil but %s.%s was called\")\n", name, m.name, name, m.name)
+		g.printf("\t}\n\n")
+		if len(returns) > 0 {
+			g.printf("\treturn m.%sFunc(%s)\n", m.name, strings.Join(args, ", "))
+		} else {
+			g.printf("\tm.%sFunc(%s)\n", m.name, strings.Join(args, ", "))
+		}
+		g.printf("}\n\n")
+
+		g.printf("// %sCalls returns the arguments of each call to %s so far.\n", m.name, m.name)
+		g.printf("func (m *%s) %sCalls() []%s {\n", name, m.name, call)
+		g.printf("\tm.mu.Lock()\n")
+		g.printf("\tdefer m.mu.Unlock()\n\n")
+		g.printf("\treturn append([]%s(nil), m.%s...)\n", call, m.record)
+		g.printf("}\n")
+	}
+
+	g.generateSkipped(iface)
+	g.generateAssertion(iface)
+	return nil
+}
+
+// resultList formats the result types of a function signature, including
+// the leading space.
+func resultList(returns []string) string {
+	switch len(returns) {
+	case 0:
+		return ""
+	case 1:
+		return " " + returns[0]
+	default:
+		return " (" + strings.Join(returns, ", ") + ")"
+	}
+}
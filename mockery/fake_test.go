@@ -0,0 +1,161 @@
+package mockery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeGenerator(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+
+	gen := NewGenerator(iface)
+	gen.Backend = Backends["fake"]
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `type Requester struct {
+	GetFunc func(path string) (string, error)
+
+	mu sync.Mutex
+	callsGet []RequesterGetCall
+}
+
+// RequesterGetCall holds the arguments of a call to Get.
+type RequesterGetCall struct {
+	Path string
+}
+
+func (m *Requester) Get(path string) (string, error) {
+	m.mu.Lock()
+	m.callsGet = append(m.callsGet, RequesterGetCall{Path: path})
+	m.mu.Unlock()
+
+	if m.GetFunc == nil {
+		panic("Requester.GetFunc is nil but Requester.Get was called")
+	}
+
+	return m.GetFunc(path)
+}
+
+// GetCalls returns the arguments of each call to Get so far.
+func (m *Requester) GetCalls() []RequesterGetCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]RequesterGetCall(nil), m.callsGet...)
+}
+
+var _ test.Requester = (*Requester)(nil)
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+}
+
+func TestFakeGeneratorNoNothing(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester4.go"))
+
+	iface, err := parser.Find("Requester4")
+
+	gen := NewGenerator(iface)
+	gen.Backend = Backends["fake"]
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `type Requester4 struct {
+	GetFunc func()
+
+	mu sync.Mutex
+	callsGet []Requester4GetCall
+}
+
+// Requester4GetCall holds the arguments of a call to Get.
+type Requester4GetCall struct {
+}
+
+func (m *Requester4) Get() {
+	m.mu.Lock()
+	m.callsGet = append(m.callsGet, Requester4GetCall{})
+	m.mu.Unlock()
+
+	if m.GetFunc == nil {
+		panic("Requester4.GetFunc is nil but Requester4.Get was called")
+	}
+
+	m.GetFunc()
+}
+
+// GetCalls returns the arguments of each call to Get so far.
+func (m *Requester4) GetCalls() []Requester4GetCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Requester4GetCall(nil), m.callsGet...)
+}
+
+var _ test.Requester4 = (*Requester4)(nil)
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+}
+
+func TestFakeGeneratorVarArg(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_vararg_mixed.go"))
+
+	iface, err := parser.Find("RequesterVarArgMixed")
+
+	gen := NewGenerator(iface)
+	gen.Backend = Backends["fake"]
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	assert.Contains(t, gen.buf.String(), "\tGetFunc func(prefix string, paths ...string) (string, error)\n")
+	assert.Contains(t, gen.buf.String(), "\tPaths []string\n")
+	assert.Contains(t, gen.buf.String(), "\treturn m.GetFunc(prefix, paths...)\n")
+}
+
+func TestFakeGeneratorUnexportedMethod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockery-fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "store.go")
+	err = ioutil.WriteFile(path, []byte("package store\n\ntype store interface {\n\tget(key string) string\n}\n"), 0644)
+	assert.NoError(t, err)
+
+	parser := NewParser()
+	err = parser.Parse(path)
+	assert.NoError(t, err)
+
+	iface, err := parser.Find("store")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.Backend = Backends["fake"]
+	gen.GenerateIPPrologue()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	assert.Contains(t, gen.buf.String(), "\tcallsGet []mockStoregetCall\n")
+	assert.Contains(t, gen.buf.String(), "func (m *mockStore) getCalls() []mockStoregetCall {\n")
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	err = gen.Verify(filepath.Join(dir, "mock_store.go"), src)
+	assert.NoError(t, err)
+}
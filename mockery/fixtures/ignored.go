@@ -0,0 +1,6 @@
+//go:build ignore
+
+package test
+
+// Requester is declared again, but this file is never built.
+type Requester struct{}
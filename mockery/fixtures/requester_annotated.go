@@ -0,0 +1,19 @@
+package test
+
+// RequesterAnnotated is mocked as FakeRequester, without Close.
+//
+//mockery:generate
+//mockery:name=FakeRequester
+type RequesterAnnotated interface {
+	Get(path string) (string, error)
+
+	// Close is left out of the mock.
+	//
+	//mockery:skip
+	Close() error
+}
+
+//mockery:skip
+type RequesterSkipped interface {
+	Get(path string) (string, error)
+}
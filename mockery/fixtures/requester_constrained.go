@@ -0,0 +1,8 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package test
+
+type RequesterConstrained interface {
+	Get(path string) (string, error)
+}
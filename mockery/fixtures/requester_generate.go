@@ -0,0 +1,11 @@
+package test
+
+// A //go:generate directive on this line mocks RequesterGenerate.
+
+type RequesterGenerate interface {
+	Get(path string) (string, error)
+}
+
+// One on this line fails, as NotAnInterface is no interface.
+
+type NotAnInterface struct{}
@@ -0,0 +1,5 @@
+package test
+
+type RequesterVarArgMixed interface {
+	Get(prefix string, paths ...string) (string, error)
+}
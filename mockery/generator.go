@@ -4,9 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"go/ast"
+	"go/build"
+	"go/build/constraint"
 	"io"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -18,90 +19,344 @@ import (
 type Generator struct {
 	buf bytes.Buffer
 
-	ip    bool
-	iface *Interface
+	// Backend renders the mock type, defaulting to testify.
+	Backend Backend
+
+	// ExpandVariadic passes the elements of a variadic parameter to
+	// Called individually rather than as a single slice, so expectations
+	// can match each element on its own.
+	ExpandVariadic bool
+
+	// Lenient adds a Lenient switch to the mock, making calls without a
+	// matching expectation return zero values rather than fail.
+	Lenient bool
+
+	// InOrder adds MockOnInOrder_* helpers, which expect calls in the order
+	// of a Sequence from InOrderPackage.
+	InOrder bool
+
+	// BuildConstraint is written as the //go:build line of the mock instead
+	// of the build constraints of the files declaring the interfaces.
+	BuildConstraint string
+
+	// NoBuildConstraint leaves the //go:build line out altogether.
+	NoBuildConstraint bool
+
+	// ImportPath is the import path GeneratePrologue imports the package
+	// declaring the interfaces with, instead of the one following from its
+	// directory in GOPATH.
+	ImportPath string
+
+	// Context selects the source files Verify checks mocks against, as the
+	// go command would for its GOOS, GOARCH and build tags. It defaults to
+	// build.Default.
+	Context *build.Context
+
+	ip     bool
+	ifaces []*Interface
+
+	// imports and aliases are filled by resolveImports.
+	imports []sourceImport
+	aliases map[*ast.File]map[string]string
 }
 
 func NewGenerator(iface *Interface) *Generator {
 	return &Generator{
-		iface: iface,
+		ifaces: []*Interface{iface},
+	}
+}
+
+// NewMultiGenerator returns a Generator writing the mocks of all of ifaces
+// to a single file. The interfaces have to be declared in the same package.
+func NewMultiGenerator(ifaces []*Interface) *Generator {
+	if len(ifaces) == 0 {
+		return &Generator{}
+	}
+
+	return &Generator{
+		ifaces: ifaces,
 	}
 }
 
 func (g *Generator) GenerateIPPrologue() {
 	g.ip = true
 
-	g.printf("package %s\n\n", g.iface.File.Name)
+	g.generateBuildConstraint()
+	g.printf("package %s\n\n", g.ifaces[0].File.Name)
 
-	g.printf("import \"github.com/stretchr/testify/mock\"\n\n")
-	if g.iface.File.Imports == nil {
-		return
-	}
+	g.generateBackendImports()
+	g.generateSourceImports()
+}
 
-	for _, imp := range g.iface.File.Imports {
-		if imp.Name == nil {
-			g.printf("import %s\n", imp.Path.Value)
-		} else {
-			g.printf("import %s %s\n", imp.Name.Name, imp.Path.Value)
-		}
+func (g *Generator) context() *build.Context {
+	if g.Context == nil {
+		return &build.Default
 	}
 
-	g.printf("\n")
+	return g.Context
 }
 
-func (g *Generator) generateMockOn(variant string, fname string, builderParams []string, onParams []string) {
-	g.printf("func (m *%s) MockOn%s_%s(%s) *mock.Call {\n", g.mockName(), variant, fname, strings.Join(builderParams, ", "))
-	g.printf("\treturn m.Mock.On(%s)\n", strings.Join(append([]string{"\"" + fname + "\""}, onParams...), ", "))
-	g.printf("}\n")
+func (g *Generator) mockName(iface *Interface) string {
+	return MockName(iface, g.ip)
 }
 
-func (g *Generator) mockName() string {
-	if g.ip {
-		if ast.IsExported(g.iface.Name) {
-			return "Mock" + g.iface.Name
+// MockName returns the name of the type mocking iface, which is declared
+// in the package of the interface if ip is set.
+func MockName(iface *Interface, ip bool) string {
+	if name := iface.Directives[DirectiveName]; name != "" {
+		return name
+	}
+
+	if ip {
+		if ast.IsExported(iface.Name) {
+			return "Mock" + iface.Name
 		} else {
-			first := true
-			return "mock" + strings.Map(func(r rune) rune {
-				if first {
-					first = false
-					return unicode.ToUpper(r)
-				}
-				return r
-			}, g.iface.Name)
+			return "mock" + upperFirst(iface.Name)
 		}
 	}
 
-	return g.iface.Name
+	return iface.Name
+}
+
+func upperFirst(s string) string {
+	first := true
+	return strings.Map(func(r rune) rune {
+		if first {
+			first = false
+			return unicode.ToUpper(r)
+		}
+		return r
+	}, s)
+}
+
+func lowerFirst(s string) string {
+	first := true
+	return strings.Map(func(r rune) rune {
+		if first {
+			first = false
+			return unicode.ToLower(r)
+		}
+		return r
+	}, s)
 }
 
 func (g *Generator) GeneratePrologue(pkg string) {
+	g.generateBuildConstraint()
 	g.printf("package %v\n\n", pkg)
 
-	goPath := os.Getenv("GOPATH")
-
-	local, err := filepath.Rel(filepath.Join(goPath, "src"), filepath.Dir(g.iface.Path))
+	local, err := g.importPath()
 	if err != nil {
 		panic("unable to figure out path for package")
 	}
 
 	g.printf("import \"%s\"\n", local)
 
-	g.printf("import \"github.com/stretchr/testify/mock\"\n\n")
-	if g.iface.File.Imports == nil {
+	g.generateBackendImports()
+	g.generateSourceImports()
+}
+
+// importPath returns the import path of the package declaring the
+// interfaces.
+func (g *Generator) importPath() (string, error) {
+	if g.ImportPath != "" {
+		return g.ImportPath, nil
+	}
+
+	return g.ifaces[0].ImportPath()
+}
+
+// generateBuildConstraint repeats the build constraints of the files
+// declaring the interfaces, so the mock is only built where they are.
+func (g *Generator) generateBuildConstraint() {
+	if g.NoBuildConstraint {
 		return
 	}
 
-	for _, imp := range g.iface.File.Imports {
-		if imp.Name == nil {
-			g.printf("import %s\n", imp.Path.Value)
+	if g.BuildConstraint != "" {
+		g.printf("//go:build %s\n\n", g.BuildConstraint)
+		return
+	}
+
+	if expr := g.sourceConstraint(); expr != nil {
+		g.printf("//go:build %s\n\n", expr)
+	}
+}
+
+// sourceConstraint returns the build constraints of the files declaring
+// the interfaces combined, or nil if they have none.
+func (g *Generator) sourceConstraint() constraint.Expr {
+	var expr constraint.Expr
+	seen := map[string]bool{}
+
+	for _, iface := range g.ifaces {
+		if iface.Constraint == nil || seen[iface.Constraint.String()] {
+			continue
+		}
+		seen[iface.Constraint.String()] = true
+
+		expr = andConstraint(expr, iface.Constraint)
+	}
+
+	return expr
+}
+
+// generateSourceImports repeats the imports of the files declaring the
+// interfaces, so the types they refer to resolve in the mocks.
+func (g *Generator) generateSourceImports() {
+	g.resolveImports()
+
+	for _, imp := range g.imports {
+		if imp.name != "" {
+			g.printf("import %s %q\n", imp.name, imp.path)
 		} else {
-			g.printf("import %s %s\n", imp.Name.Name, imp.Path.Value)
+			g.printf("import %q\n", imp.path)
+		}
+	}
+
+	if len(g.imports) > 0 {
+		g.printf("\n")
+	}
+}
+
+// sourceImport is an import of the files declaring the interfaces, as the
+// mocks import it.
+type sourceImport struct {
+	name string // empty to import the package under its own name
+	path string
+}
+
+// resolveImports collects the imports of the files declaring the
+// interfaces, once. An import that would take a name already taken by
+// another package, whether imported by another of the files or by the
+// mocks themselves, is given a name of its own, which typeString refers to
+// it by in the types of the file importing it.
+func (g *Generator) resolveImports() {
+	if g.aliases != nil {
+		return
+	}
+	g.aliases = map[*ast.File]map[string]string{}
+
+	// taken holds the path of the package imported under each name.
+	taken := map[string]string{}
+	for _, path := range g.backendImports() {
+		taken[importName(path)] = path
+	}
+	if !g.ip {
+		if local, err := g.importPath(); err == nil {
+			taken[g.ifaces[0].File.Name.Name] = local
+		}
+	}
+
+	files := map[*ast.File]bool{}
+	blank := map[sourceImport]bool{}
+
+	for _, iface := range g.ifaces {
+		if files[iface.File] {
+			continue
+		}
+		files[iface.File] = true
+
+		for _, spec := range iface.File.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+
+			name := importName(path)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+
+			// Blank and dot imports bind no name of their own.
+			if name == "_" || name == "." {
+				imp := sourceImport{name, path}
+				if !blank[imp] {
+					blank[imp] = true
+					g.imports = append(g.imports, imp)
+				}
+				continue
+			}
+
+			alias := name
+			for n := 2; taken[alias] != "" && taken[alias] != path; n++ {
+				alias = fmt.Sprintf("%s%d", name, n)
+			}
+			if alias != name {
+				if g.aliases[iface.File] == nil {
+					g.aliases[iface.File] = map[string]string{}
+				}
+				g.aliases[iface.File][name] = alias
+			}
+
+			if taken[alias] == path {
+				continue
+			}
+			taken[alias] = path
+
+			imp := sourceImport{path: path}
+			if spec.Name != nil || alias != name {
+				imp.name = alias
+			}
+			g.imports = append(g.imports, imp)
 		}
 	}
+}
+
+// importAlias returns the name the mocks refer to the package imported as
+// name by the file declaring iface.
+func (g *Generator) importAlias(iface *Interface, name string) string {
+	g.resolveImports()
+
+	if alias, ok := g.aliases[iface.File][name]; ok {
+		return alias
+	}
+
+	return name
+}
+
+// importName guesses the name of the package at path from its last
+// element, leaving out a major version, as in gopkg.in/yaml.v2 or
+// example.com/pkg/v2, and a go- prefix.
+func importName(path string) string {
+	elems := strings.Split(path, "/")
+
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+
+	name = strings.TrimPrefix(name, "go-")
+	if idx := strings.IndexAny(name, ".-"); idx > 0 {
+		name = name[:idx]
+	}
 
+	return name
+}
+
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+
+	_, err := strconv.Atoi(elem[1:])
+	return err == nil
+}
+
+func (g *Generator) generateBackendImports() {
+	for _, imp := range g.backendImports() {
+		g.printf("import \"%s\"\n", imp)
+	}
 	g.printf("\n")
 }
 
+// backendImports returns the packages the mocks import whatever the
+// interfaces.
+func (g *Generator) backendImports() []string {
+	imports := g.backend().Imports(g)
+	if g.InOrder {
+		imports = append(imports[:len(imports):len(imports)], InOrderPackage)
+	}
+
+	return imports
+}
+
 func (g *Generator) GeneratePrologueNote(note string) {
 	if note != "" {
 		g.printf("\n")
@@ -146,7 +401,7 @@ var builtinTypes = map[string]bool{
 	"uintptr":     true,
 }
 
-func (g *Generator) typeString(typ ast.Expr) string {
+func (g *Generator) typeString(iface *Interface, typ ast.Expr) string {
 	switch specific := typ.(type) {
 	case *ast.Ident:
 		if g.ip {
@@ -158,12 +413,12 @@ func (g *Generator) typeString(typ ast.Expr) string {
 			return specific.Name
 		}
 
-		return g.iface.File.Name.Name + "." + specific.Name
+		return iface.File.Name.Name + "." + specific.Name
 	case *ast.StarExpr:
-		return "*" + g.typeString(specific.X)
+		return "*" + g.typeString(iface, specific.X)
 	case *ast.ArrayType:
 		if specific.Len == nil {
-			return "[]" + g.typeString(specific.Elt)
+			return "[]" + g.typeString(iface, specific.Elt)
 		} else {
 			var l string
 
@@ -173,11 +428,11 @@ func (g *Generator) typeString(typ ast.Expr) string {
 			default:
 				panic(fmt.Sprintf("unable to figure out array length: %#v", specific.Len))
 			}
-			return "[" + l + "]" + g.typeString(specific.Elt)
+			return "[" + l + "]" + g.typeString(iface, specific.Elt)
 		}
 	case *ast.SelectorExpr:
 		if ident, ok := specific.X.(*ast.Ident); ok {
-			return ident.Name + "." + specific.Sel.Name
+			return g.importAlias(iface, ident.Name) + "." + specific.Sel.Name
 		} else {
 			panic(fmt.Sprintf("strange selector expr encountered: %#v", specific))
 		}
@@ -188,26 +443,26 @@ func (g *Generator) typeString(typ ast.Expr) string {
 			panic(fmt.Sprintf("unable to handle this interface type: %#v", specific))
 		}
 	case *ast.MapType:
-		return "map[" + g.typeString(specific.Key) + "]" + g.typeString(specific.Value)
+		return "map[" + g.typeString(iface, specific.Key) + "]" + g.typeString(iface, specific.Value)
 	case *ast.Ellipsis:
-		return "..." + g.typeString(specific.Elt)
+		return "..." + g.typeString(iface, specific.Elt)
 	case *ast.FuncType:
-		return "func(" + g.typeFieldList(specific.Params, false) + ") " + g.typeFieldList(specific.Results, true)
+		return "func(" + g.typeFieldList(iface, specific.Params, false) + ") " + g.typeFieldList(iface, specific.Results, true)
 	case *ast.ChanType:
 		switch specific.Dir {
 		case ast.SEND:
-			return "chan<- " + g.typeString(specific.Value)
+			return "chan<- " + g.typeString(iface, specific.Value)
 		case ast.RECV:
-			return "<-chan " + g.typeString(specific.Value)
+			return "<-chan " + g.typeString(iface, specific.Value)
 		default:
-			return "chan " + g.typeString(specific.Value)
+			return "chan " + g.typeString(iface, specific.Value)
 		}
 	default:
 		panic(fmt.Sprintf("unable to handle type: %#v", typ))
 	}
 }
 
-func (g *Generator) typeFieldList(fl *ast.FieldList, optParen bool) string {
+func (g *Generator) typeFieldList(iface *Interface, fl *ast.FieldList, optParen bool) string {
 	var list []string
 
 	if fl == nil {
@@ -220,7 +475,7 @@ func (g *Generator) typeFieldList(fl *ast.FieldList, optParen bool) string {
 		}
 
 		for i := 0; i < cnt; i++ {
-			list = append(list, g.typeString(field.Type))
+			list = append(list, g.typeString(iface, field.Type))
 		}
 	}
 
@@ -235,7 +490,7 @@ func (g *Generator) typeFieldList(fl *ast.FieldList, optParen bool) string {
 	return strings.Join(list, ", ")
 }
 
-func (g *Generator) genList(list *ast.FieldList, addNames bool) ([]string, []string, []string, []string) {
+func (g *Generator) genList(iface *Interface, list *ast.FieldList, addNames bool) ([]string, []string, []string, []string) {
 	var (
 		params []string
 		names  []string
@@ -248,7 +503,7 @@ func (g *Generator) genList(list *ast.FieldList, addNames bool) ([]string, []str
 	}
 
 	for idx, param := range list.List {
-		ts := g.typeString(param.Type)
+		ts := g.typeString(iface, param.Type)
 
 		var pname string
 
@@ -293,80 +548,117 @@ func (g *Generator) argName(pname string, typ ast.Expr) string {
 var ErrNotSetup = errors.New("not setup")
 
 func (g *Generator) Generate() error {
-	if g.iface == nil {
+	if len(g.ifaces) == 0 {
 		return ErrNotSetup
 	}
 
-	g.printf("type %s struct {\n\tmock.Mock\n}\n\n", g.mockName())
+	if !g.NoBuildConstraint && g.BuildConstraint == "" {
+		if expr := g.sourceConstraint(); expr != nil && !satisfiable(expr) {
+			var names []string
+			for _, iface := range g.ifaces {
+				names = append(names, iface.Name)
+			}
+			return fmt.Errorf("the build constraints of %s never hold together: %s", strings.Join(names, ", "), expr)
+		}
+	}
 
-	for _, method := range g.iface.Type.Methods.List {
-		ftype, ok := method.Type.(*ast.FuncType)
-		if !ok {
-			continue
+	for idx, iface := range g.ifaces {
+		if idx > 0 {
+			g.printf("\n")
 		}
 
+		err := g.backend().Generate(g, iface)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// generateAssertion writes the assertion of the mock of iface, for
+// backends to call once they wrote the mock.
+func (g *Generator) generateAssertion(iface *Interface) {
+	if assertion := g.assertion(iface); assertion != "" {
+		g.printf("\n%s\n", assertion)
+	}
+}
+
+// assertion returns the declaration of a blank variable of the interface
+// type holding the mock, so the mock stops compiling as soon as it no
+// longer implements the interface. It is empty for unexported interfaces
+// mocked outside their package, which can not be named there.
+func (g *Generator) assertion(iface *Interface) string {
+	if !g.canAssert(iface) {
+		return ""
+	}
+
+	return fmt.Sprintf("var _ %s = (*%s)(nil)", g.interfaceType(iface), g.mockName(iface))
+}
+
+func (g *Generator) canAssert(iface *Interface) bool {
+	return g.ip || ast.IsExported(iface.Name)
+}
+
+// skippedMethods returns the methods of iface a skip directive leaves out
+// of the mock.
+func (g *Generator) skippedMethods(iface *Interface) []*ast.Field {
+	var methods []*ast.Field
+
+	for _, method := range iface.Type.Methods.List {
+		if _, ok := method.Type.(*ast.FuncType); ok && ParseDirectives(method.Doc).Has(DirectiveSkip) {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods
+}
+
+// generateSkipped writes a method panicking when called for each method a
+// skip directive leaves out of the mock of iface, so the mock still
+// implements the interface. Backends call it before the assertion.
+func (g *Generator) generateSkipped(iface *Interface) {
+	name := g.mockName(iface)
+
+	for _, method := range g.skippedMethods(iface) {
+		ftype := method.Type.(*ast.FuncType)
 		fname := method.Names[0].Name
 
-		paramNames, paramTypes, params, args := g.genList(ftype.Params, true)
-		_, returnTypes, returns, _ := g.genList(ftype.Results, false)
+		_, _, params, _ := g.genList(iface, ftype.Params, true)
+		_, _, returns, _ := g.genList(iface, ftype.Results, false)
 
-		g.printf("func (m *%s) Name_%s() string {\n", g.mockName(), fname)
-		g.printf("\treturn %s\n", "\""+fname+"\"")
+		g.printf("\nfunc (m *%s) %s(%s)%s {\n", name, fname, strings.Join(params, ", "), resultList(returns))
+		g.printf("\tpanic(\"%s.%s is left out of the mock by a skip directive\")\n", name, fname)
 		g.printf("}\n")
+	}
+}
 
-		paramsInterface := []string{}
-		paramsAnything := []string{}
-		for _, p := range paramNames {
-			paramsInterface = append(paramsInterface, p+" interface{}")
-			paramsAnything = append(paramsAnything, "mock.Anything")
-		}
-		g.generateMockOn("", fname, paramsInterface, paramNames)
-		g.generateMockOn("Typed", fname, params, paramNames)
-		g.generateMockOn("Any", fname, []string{}, paramsAnything)
+// methods returns the methods of iface to mock, leaving out those with a
+// skip directive.
+func (g *Generator) methods(iface *Interface) []*ast.Field {
+	var methods []*ast.Field
 
-		g.printf("func (m *%s) %s(%s) ", g.mockName(), fname, strings.Join(params, ", "))
+	for _, method := range iface.Type.Methods.List {
+		if _, ok := method.Type.(*ast.FuncType); !ok {
+			continue
+		}
 
-		switch len(returns) {
-		case 0:
-			g.printf("{\n")
-		case 1:
-			g.printf("%s {\n", returns[0])
-		default:
-			g.printf("(%s) {\n", strings.Join(returns, ", "))
+		if ParseDirectives(method.Doc).Has(DirectiveSkip) {
+			continue
 		}
-		if len(returnTypes) > 0 {
-			g.printf("\tret := m.Called(%s)\n\n", strings.Join(paramNames, ", "))
-
-			var ret []string
-
-			for idx, typ := range returnTypes {
-				g.printf("\tvar r%d %s\n", idx, typ)
-				g.printf("\tif rf, ok := ret.Get(%d).(func(%s) %s); ok {\n", idx, strings.Join(paramTypes, ", "), typ)
-				g.printf("\t\tr%d = rf(%s)\n", idx, strings.Join(args, ", "))
-				g.printf("\t} else {\n")
-				if typ == "error" {
-					g.printf("\t\tr%d = ret.Error(%d)\n", idx, idx)
-				} else if g.isNillable(ftype.Results.List[idx].Type) {
-					g.printf("\t\tif ret.Get(%d) != nil {\n", idx)
-					g.printf("\t\t\tr%d = ret.Get(%d).(%s)\n", idx, idx, typ)
-					g.printf("\t\t}\n")
-				} else {
-					g.printf("\t\tr%d = ret.Get(%d).(%s)\n", idx, idx, typ)
-				}
-				g.printf("\t}\n\n")
-				ret = append(ret, fmt.Sprintf("r%d", idx))
-			}
 
-			g.printf("\treturn %s\n", strings.Join(ret, ", "))
+		methods = append(methods, method)
+	}
 
-		} else {
-			g.printf("\tm.Called(%s)\n", strings.Join(paramNames, ", "))
-		}
+	return methods
+}
 
-		g.printf("}\n")
+func (g *Generator) interfaceType(iface *Interface) string {
+	if g.ip {
+		return iface.Name
 	}
 
-	return nil
+	return iface.File.Name.Name + "." + iface.Name
 }
 
 func (g *Generator) isNillable(typ ast.Expr) bool {
@@ -377,9 +669,17 @@ func (g *Generator) isNillable(typ ast.Expr) bool {
 	return false
 }
 
-func (g *Generator) Write(w io.Writer) error {
+// Bytes returns the formatted source generated so far, headed by Header
+// and with unused imports removed.
+func (g *Generator) Bytes() ([]byte, error) {
+	src := append([]byte(Header+"\n\n"), g.buf.Bytes()...)
+
 	opt := &imports.Options{Comments: true}
-	res, err := imports.Process("mock.go", g.buf.Bytes(), opt)
+	return imports.Process("mock.go", src, opt)
+}
+
+func (g *Generator) Write(w io.Writer) error {
+	res, err := g.Bytes()
 	if err != nil {
 		return err
 	}
@@ -1,10 +1,14 @@
 package mockery
 
 import (
+	"errors"
+	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestGenerator(t *testing.T) {
@@ -53,6 +57,8 @@ func (m *Requester) Get(path string) (string, error) {
 
 	return r0, r1
 }
+
+var _ test.Requester = (*Requester)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -97,6 +103,8 @@ func (m *Requester2) Get(path string) error {
 
 	return r0
 }
+
+var _ test.Requester2 = (*Requester2)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -141,6 +149,8 @@ func (m *Requester3) Get() error {
 
 	return r0
 }
+
+var _ test.Requester3 = (*Requester3)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -176,6 +186,8 @@ func (m *Requester4) MockOnAny_Get() *mock.Call {
 func (m *Requester4) Get() {
 	m.Called()
 }
+
+var _ test.Requester4 = (*Requester4)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -212,6 +224,8 @@ func (m *mockRequester) MockOnAny_Get() *mock.Call {
 func (m *mockRequester) Get() {
 	m.Called()
 }
+
+var _ requester = (*mockRequester)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -238,6 +252,28 @@ import "github.com/stretchr/testify/mock"
 	assert.Equal(t, expected, gen.buf.String())
 }
 
+func TestGeneratorPrologueImportPath(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.ImportPath = "example.com/test"
+
+	gen.GeneratePrologue("mocks")
+
+	expected := `package mocks
+
+import "example.com/test"
+import "github.com/stretchr/testify/mock"
+
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+}
+
 func TestGeneratorProloguewithImports(t *testing.T) {
 	parser := NewParser()
 	parser.Parse(filepath.Join(fixturePath, "requester_ns.go"))
@@ -261,6 +297,196 @@ import "net/http"
 	assert.Equal(t, expected, gen.buf.String())
 }
 
+func TestGeneratorPrologueBuildConstraint(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_constrained.go"))
+
+	iface, err := parser.Find("RequesterConstrained")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+
+	gen.GenerateIPPrologue()
+
+	expected := `//go:build linux || darwin
+
+package test
+
+import "github.com/stretchr/testify/mock"
+
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+
+	gen = NewGenerator(iface)
+	gen.BuildConstraint = "integration"
+
+	gen.GenerateIPPrologue()
+
+	assert.True(t, strings.HasPrefix(gen.buf.String(), "//go:build integration\n\npackage test\n"))
+
+	gen = NewGenerator(iface)
+	gen.NoBuildConstraint = true
+
+	gen.GenerateIPPrologue()
+
+	assert.True(t, strings.HasPrefix(gen.buf.String(), "package test\n"))
+}
+
+func TestGeneratorMultiPrologue(t *testing.T) {
+	parser := NewParser()
+
+	sources := []struct {
+		path, name, src string
+	}{
+		{"/src/store/pages.go", "Pages", "package store\n\nimport (\n\t\"html/template\"\n\t\"net/http\"\n)\n\ntype Pages interface {\n\tPage(r *http.Request) *template.Template\n}\n"},
+		{"/src/store/mails.go", "Mails", "package store\n\nimport (\n\t\"net/http\"\n\t\"text/template\"\n)\n\ntype Mails interface {\n\tMail(r *http.Request) *template.Template\n}\n"},
+		{"/src/store/fakes.go", "Fakes", "package store\n\nimport \"example.com/mock\"\n\ntype Fakes interface {\n\tFake() mock.Fake\n}\n"},
+	}
+
+	// The imports of net/http are merged, while text/template and the mock
+	// package of example.com get names of their own.
+	var ifaces []*Interface
+	for _, source := range sources {
+		err := parser.ParseSource(source.path, []byte(source.src))
+		assert.NoError(t, err)
+
+		iface, err := parser.Find(source.name)
+		assert.NoError(t, err)
+		ifaces = append(ifaces, iface)
+	}
+
+	gen := NewMultiGenerator(ifaces)
+	gen.ImportPath = "example.com/store"
+
+	gen.GeneratePrologue("mocks")
+
+	expected := `package mocks
+
+import "example.com/store"
+import "github.com/stretchr/testify/mock"
+
+import "html/template"
+import "net/http"
+import template2 "text/template"
+import mock2 "example.com/mock"
+
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+
+	err := gen.Generate()
+	assert.NoError(t, err)
+
+	assert.Contains(t, gen.buf.String(), "func (m *Pages) Page(r *http.Request) *template.Template {\n")
+	assert.Contains(t, gen.buf.String(), "func (m *Mails) Mail(r *http.Request) *template2.Template {\n")
+	assert.Contains(t, gen.buf.String(), "func (m *Fakes) Fake() mock2.Fake {\n")
+}
+
+func TestImportName(t *testing.T) {
+	cases := map[string]string{
+		"fmt":                              "fmt",
+		"net/http":                         "http",
+		"gopkg.in/yaml.v2":                 "yaml",
+		"example.com/pkg/v2":               "pkg",
+		"github.com/mattn/go-isatty":       "isatty",
+		"github.com/stretchr/testify/mock": "mock",
+	}
+
+	for path, name := range cases {
+		assert.Equal(t, name, importName(path), path)
+	}
+}
+
+func TestGeneratorMulti(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	parser.Parse(filepath.Join(fixturePath, "requester_ns.go"))
+
+	ifaceNS, err := parser.Find("RequesterNS")
+	assert.NoError(t, err)
+
+	gen := NewMultiGenerator([]*Interface{iface, ifaceNS})
+
+	gen.GeneratePrologue("mocks")
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	out := string(src)
+
+	assert.Equal(t, 1, strings.Count(out, "\npackage "))
+	assert.Contains(t, out, "\t\"net/http\"\n")
+	assert.Contains(t, out, "type Requester struct {")
+	assert.Contains(t, out, "type RequesterNS struct {")
+	assert.Contains(t, out, "var _ test.Requester = (*Requester)(nil)")
+	assert.Contains(t, out, "var _ test.RequesterNS = (*RequesterNS)(nil)")
+}
+
+func TestGeneratorDirectives(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_annotated.go"))
+
+	iface, err := parser.Find("RequesterAnnotated")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	out := gen.buf.String()
+
+	assert.Contains(t, out, "type FakeRequester struct {")
+	assert.Contains(t, out, "func (m *FakeRequester) Get(path string) (string, error) {")
+	assert.NotContains(t, out, "MockOn_Close")
+	assert.Contains(t, out, "func (m *FakeRequester) Close() error {\n\tpanic(\"FakeRequester.Close is left out of the mock by a skip directive\")\n}\n")
+	assert.Contains(t, out, "var _ test.RequesterAnnotated = (*FakeRequester)(nil)")
+}
+
+func TestGeneratorSkippedMethods(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_annotated.go"))
+
+	iface, err := parser.Find("RequesterAnnotated")
+	assert.NoError(t, err)
+
+	for name, backend := range Backends {
+		gen := NewGenerator(iface)
+		gen.Backend = backend
+		gen.GenerateIPPrologue()
+
+		err = gen.Generate()
+		assert.NoError(t, err, name)
+
+		src, err := gen.Bytes()
+		assert.NoError(t, err, name)
+
+		// A recorder passes skipped methods on to the implementation it
+		// wraps, without recording them.
+		if name == "recorder" {
+			assert.Contains(t, string(src), "func (m *FakeRequester) Close() error {\n\treturn m.Real.Close()\n}\n", name)
+		} else {
+			assert.Contains(t, string(src), "func (m *FakeRequester) Close() error {\n\tpanic(\"FakeRequester.Close is left out of the mock by a skip directive\")\n}\n", name)
+		}
+		assert.Contains(t, string(src), "var _ RequesterAnnotated = (*FakeRequester)(nil)", name)
+
+		// gomock is not a dependency of mockery, so its mocks do not
+		// type-check here.
+		if name != "gomock" {
+			err = gen.Verify(filepath.Join(fixturePath, "mock_FakeRequester.go"), src)
+			assert.NoError(t, err, name)
+		}
+	}
+}
+
 func TestGeneratorPointers(t *testing.T) {
 	parser := NewParser()
 	parser.Parse(filepath.Join(fixturePath, "requester_ptr.go"))
@@ -311,6 +537,8 @@ func (m *RequesterPtr) Get(path string) (*string, error) {
 
 	return r0, r1
 }
+
+var _ test.RequesterPtr = (*RequesterPtr)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -366,6 +594,8 @@ func (m *RequesterSlice) Get(path string) ([]string, error) {
 
 	return r0, r1
 }
+
+var _ test.RequesterSlice = (*RequesterSlice)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -421,6 +651,8 @@ func (m *RequesterArray) Get(path string) ([2]string, error) {
 
 	return r0, r1
 }
+
+var _ test.RequesterArray = (*RequesterArray)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -465,11 +697,178 @@ func (m *RequesterVarArg) Get(paths ...string) error {
 
 	return r0
 }
+
+var _ test.RequesterVarArg = (*RequesterVarArg)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
 }
 
+func TestGeneratorVarArgExpanded(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_vararg.go"))
+
+	iface, err := parser.Find("RequesterVarArg")
+
+	gen := NewGenerator(iface)
+	gen.ExpandVariadic = true
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `type RequesterVarArg struct {
+	mock.Mock
+}
+
+func (m *RequesterVarArg) Name_Get() string {
+	return "Get"
+}
+func (m *RequesterVarArg) MockOn_Get(paths ...interface{}) *mock.Call {
+	_ca := append([]interface{}{}, paths...)
+	return m.Mock.On("Get", _ca...)
+}
+func (m *RequesterVarArg) MockOnTyped_Get(paths ...string) *mock.Call {
+	_ca := []interface{}{}
+	for _, _va := range paths {
+		_ca = append(_ca, _va)
+	}
+	return m.Mock.On("Get", _ca...)
+}
+func (m *RequesterVarArg) MockOnAny_Get() *mock.Call {
+	return m.Mock.On("Get")
+}
+func (m *RequesterVarArg) Get(paths ...string) error {
+	_ca := []interface{}{}
+	for _, _va := range paths {
+		_ca = append(_ca, _va)
+	}
+	ret := m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(...string) error); ok {
+		r0 = rf(paths...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+var _ test.RequesterVarArg = (*RequesterVarArg)(nil)
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+}
+
+func TestGeneratorVarArgExpandedMixed(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_vararg_mixed.go"))
+
+	iface, err := parser.Find("RequesterVarArgMixed")
+
+	gen := NewGenerator(iface)
+	gen.ExpandVariadic = true
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `type RequesterVarArgMixed struct {
+	mock.Mock
+}
+
+func (m *RequesterVarArgMixed) Name_Get() string {
+	return "Get"
+}
+func (m *RequesterVarArgMixed) MockOn_Get(prefix interface{}, paths ...interface{}) *mock.Call {
+	_ca := append([]interface{}{prefix}, paths...)
+	return m.Mock.On("Get", _ca...)
+}
+func (m *RequesterVarArgMixed) MockOnTyped_Get(prefix string, paths ...string) *mock.Call {
+	_ca := []interface{}{prefix}
+	for _, _va := range paths {
+		_ca = append(_ca, _va)
+	}
+	return m.Mock.On("Get", _ca...)
+}
+func (m *RequesterVarArgMixed) MockOnAny_Get() *mock.Call {
+	return m.Mock.On("Get", mock.Anything)
+}
+func (m *RequesterVarArgMixed) Get(prefix string, paths ...string) (string, error) {
+	_ca := []interface{}{prefix}
+	for _, _va := range paths {
+		_ca = append(_ca, _va)
+	}
+	ret := m.Called(_ca...)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, ...string) string); ok {
+		r0 = rf(prefix, paths...)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, ...string) error); ok {
+		r1 = rf(prefix, paths...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+var _ test.RequesterVarArgMixed = (*RequesterVarArgMixed)(nil)
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+}
+
+// varArgMixed holds the methods generated for RequesterVarArgMixed with
+// ExpandVariadic, as expected by TestGeneratorVarArgExpandedMixed, to check
+// which calls they match.
+type varArgMixed struct {
+	mock.Mock
+}
+
+func (m *varArgMixed) MockOn_Get(prefix interface{}, paths ...interface{}) *mock.Call {
+	_ca := append([]interface{}{prefix}, paths...)
+	return m.Mock.On("Get", _ca...)
+}
+func (m *varArgMixed) MockOnAny_Get() *mock.Call {
+	return m.Mock.On("Get", mock.Anything)
+}
+func (m *varArgMixed) Get(prefix string, paths ...string) (string, error) {
+	_ca := []interface{}{prefix}
+	for _, _va := range paths {
+		_ca = append(_ca, _va)
+	}
+	ret := m.Called(_ca...)
+
+	return ret.String(0), ret.Error(1)
+}
+
+func TestGeneratorVarArgExpandedAny(t *testing.T) {
+	m := &varArgMixed{}
+	m.MockOnAny_Get().Return("any", nil)
+	m.MockOn_Get(mock.Anything, mock.Anything, mock.Anything).Return("some", nil)
+
+	res, err := m.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "any", res)
+
+	// Calls passing variadic arguments are not matched by MockOnAny_Get, but
+	// by expectations giving mock.Anything for as many of them or more.
+	res, err = m.Get("a", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, "some", res)
+
+	res, err = m.Get("a", "b", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, "some", res)
+
+	assert.Panics(t, func() { m.Get("a", "b", "c", "d") })
+}
+
 func TestGeneratorNamespacedTypes(t *testing.T) {
 	parser := NewParser()
 	parser.Parse(filepath.Join(fixturePath, "requester_ns.go"))
@@ -518,6 +917,8 @@ func (m *RequesterNS) Get(path string) (http.Response, error) {
 
 	return r0, r1
 }
+
+var _ test.RequesterNS = (*RequesterNS)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -576,6 +977,8 @@ func (m *KeyManager) GetKey(_a0 string, _a1 uint16) ([]byte, *test.Err) {
 
 	return r0, r1
 }
+
+var _ test.KeyManager = (*KeyManager)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -620,6 +1023,8 @@ func (m *RequesterElided) Get(path string, url string) error {
 
 	return r0
 }
+
+var _ test.RequesterElided = (*RequesterElided)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -705,6 +1110,8 @@ func (m *Fooer) Baz(path string) func(string) string {
 
 	return r0
 }
+
+var _ test.Fooer = (*Fooer)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
@@ -803,7 +1210,169 @@ func (m *AsyncProducer) Whatever() chan bool {
 
 	return r0
 }
+
+var _ test.AsyncProducer = (*AsyncProducer)(nil)
 `
 
 	assert.Equal(t, expected, gen.buf.String())
 }
+
+func TestGeneratorLenient(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	parser.Parse(filepath.Join(fixturePath, "requester3.go"))
+	iface3, err := parser.Find("Requester3")
+	assert.NoError(t, err)
+
+	gen := NewMultiGenerator([]*Interface{iface, iface3})
+	gen.Lenient = true
+	gen.ImportPath = "github.com/ryanbrainard/mockery/mockery/fixtures"
+	gen.GeneratePrologue("mockery")
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	// lenient_mock_test.go holds the same mocks, for the tests below to run.
+	expected, err := ioutil.ReadFile("lenient_mock_test.go")
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(src))
+}
+
+func TestLenientMock(t *testing.T) {
+	m := &Requester{Lenient: true}
+	m.MockOnTyped_Get("/foo").Return("bar", nil).Once()
+
+	res, err := m.Get("/foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", res)
+
+	// Unexpected calls, and those past the limit of an expectation, return
+	// zero values and are recorded.
+	res, err = m.Get("/baz")
+	assert.NoError(t, err)
+	assert.Equal(t, "", res)
+
+	res, err = m.Get("/foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "", res)
+
+	m.AssertCalled(t, "Get", "/baz")
+	m.AssertNumberOfCalls(t, "Get", 3)
+
+	// Expectations set up after a fallback still match.
+	m.MockOnTyped_Get("/baz").Return("qux", nil)
+
+	res, err = m.Get("/baz")
+	assert.NoError(t, err)
+	assert.Equal(t, "qux", res)
+
+	m.AssertExpectations(t)
+}
+
+func TestLenientMockNoArguments(t *testing.T) {
+	m := &Requester3{Lenient: true}
+
+	assert.NoError(t, m.Get())
+
+	// A fallback for a call without arguments matches any such call, so
+	// an expectation set up after one has to be found before falling back.
+	m.MockOn_Get().Return(errors.New("closed")).Once()
+	assert.EqualError(t, m.Get(), "closed")
+	assert.NoError(t, m.Get())
+
+	m.AssertNumberOfCalls(t, "Get", 3)
+	m.AssertExpectations(t)
+}
+
+func TestLenientMockStrict(t *testing.T) {
+	m := &Requester{}
+
+	assert.Panics(t, func() { m.Get("/foo") })
+}
+
+func TestGeneratorLenientVarArg(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_vararg.go"))
+
+	iface, err := parser.Find("RequesterVarArg")
+
+	gen := NewGenerator(iface)
+	gen.Lenient = true
+	gen.ExpandVariadic = true
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `func (m *RequesterVarArg) Get(paths ...string) error {
+	_ca := []interface{}{}
+	for _, _va := range paths {
+		_ca = append(_ca, _va)
+	}
+	if m.Lenient {
+		var r0 error
+		m.expectFallback("Get", _ca, r0)
+	}
+
+	ret := m.Called(_ca...)
+`
+
+	assert.Contains(t, gen.buf.String(), expected)
+}
+
+func TestGeneratorInOrder(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.InOrder = true
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `func (m *Requester) MockOnAny_Get() *mock.Call {
+	return m.Mock.On("Get", mock.Anything)
+}
+func (m *Requester) MockOnInOrder_Get(_seq *inorder.Sequence, path string) *mock.Call {
+	return _seq.Add(m.Mock.On("Get", path))
+}
+func (m *Requester) Get(path string) (string, error) {
+`
+
+	assert.Contains(t, gen.buf.String(), expected)
+}
+
+func TestGeneratorInOrderVarArg(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_vararg_mixed.go"))
+
+	iface, err := parser.Find("RequesterVarArgMixed")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.InOrder = true
+	gen.ExpandVariadic = true
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `func (m *RequesterVarArgMixed) MockOnInOrder_Get(_seq *inorder.Sequence, prefix string, paths ...string) *mock.Call {
+	_ca := []interface{}{prefix}
+	for _, _va := range paths {
+		_ca = append(_ca, _va)
+	}
+	return _seq.Add(m.Mock.On("Get", _ca...))
+}
+`
+
+	assert.Contains(t, gen.buf.String(), expected)
+}
@@ -0,0 +1,123 @@
+package mockery
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// gomockBackend renders mocks in the style of golang/mock: the mock is
+// driven by a *gomock.Controller and expectations are set through the
+// recorder returned by EXPECT().
+type gomockBackend struct{}
+
+func (gomockBackend) Imports(g *Generator) []string {
+	return []string{"github.com/golang/mock/gomock", "reflect"}
+}
+
+func (gomockBackend) Generate(g *Generator, iface *Interface) error {
+	name := g.mockName(iface)
+	recorder := name + "Recorder"
+
+	g.printf("type %s struct {\n", name)
+	g.printf("\tctrl     *gomock.Controller\n")
+	g.printf("\trecorder *%s\n", recorder)
+	g.printf("}\n\n")
+
+	g.printf("type %s struct {\n", recorder)
+	g.printf("\tmock *%s\n", name)
+	g.printf("}\n\n")
+
+	g.printf("func %s(ctrl *gomock.Controller) *%s {\n", constructorName(name), name)
+	g.printf("\tm := &%s{ctrl: ctrl}\n", name)
+	g.printf("\tm.recorder = &%s{m}\n", recorder)
+	g.printf("\treturn m\n")
+	g.printf("}\n\n")
+
+	g.printf("func (m *%s) EXPECT() *%s {\n", name, recorder)
+	g.printf("\treturn m.recorder\n")
+	g.printf("}\n")
+
+	for _, method := range g.methods(iface) {
+		ftype := method.Type.(*ast.FuncType)
+
+		fname := method.Names[0].Name
+
+		paramNames, paramTypes, params, _ := g.genList(iface, ftype.Params, true)
+		_, returnTypes, returns, _ := g.genList(iface, ftype.Results, false)
+
+		variadic := len(paramTypes) > 0 && strings.HasPrefix(paramTypes[len(paramTypes)-1], "...")
+
+		callArgs := append([]string{"m", "\"" + fname + "\""}, paramNames...)
+		if variadic {
+			callArgs = []string{"m", "\"" + fname + "\"", "_ca..."}
+		}
+
+		g.printf("func (m *%s) %s(%s) ", name, fname, strings.Join(params, ", "))
+
+		switch len(returns) {
+		case 0:
+			g.printf("{\n")
+		case 1:
+			g.printf("%s {\n", returns[0])
+		default:
+			g.printf("(%s) {\n", strings.Join(returns, ", "))
+		}
+
+		if variadic {
+			g.printf("%s\n", variadicArgs(paramNames, true))
+		}
+
+		if len(returnTypes) > 0 {
+			g.printf("\tret := m.ctrl.Call(%s)\n", strings.Join(callArgs, ", "))
+
+			var ret []string
+			for idx, typ := range returnTypes {
+				g.printf("\tr%d, _ := ret[%d].(%s)\n", idx, idx, typ)
+				ret = append(ret, fmt.Sprintf("r%d", idx))
+			}
+
+			g.printf("\treturn %s\n", strings.Join(ret, ", "))
+		} else {
+			g.printf("\tm.ctrl.Call(%s)\n", strings.Join(callArgs, ", "))
+		}
+
+		g.printf("}\n")
+
+		recorderParams := []string{}
+		for _, p := range paramNames {
+			recorderParams = append(recorderParams, p+" interface{}")
+		}
+		recordArgs := append([]string{
+			"mr.mock",
+			"\"" + fname + "\"",
+			fmt.Sprintf("reflect.TypeOf((*%s)(nil).%s)", name, fname),
+		}, paramNames...)
+		if variadic {
+			last := len(paramNames) - 1
+			recorderParams[last] = paramNames[last] + " ...interface{}"
+			recordArgs = append(recordArgs[:3], "_ca...")
+		}
+
+		g.printf("func (mr *%s) %s(%s) *gomock.Call {\n", recorder, fname, strings.Join(recorderParams, ", "))
+		if variadic {
+			g.printf("%s\n", variadicArgs(paramNames, false))
+		}
+		g.printf("\treturn mr.mock.ctrl.RecordCallWithMethodType(%s)\n", strings.Join(recordArgs, ", "))
+		g.printf("}\n")
+	}
+
+	g.generateSkipped(iface)
+	g.generateAssertion(iface)
+	return nil
+}
+
+// constructorName returns the name of the function creating a mock of type
+// name, keeping it unexported if the type is.
+func constructorName(name string) string {
+	if ast.IsExported(name) {
+		return "New" + name
+	}
+
+	return "new" + upperFirst(name)
+}
@@ -0,0 +1,131 @@
+package mockery
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGomockGenerator(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+
+	gen := NewGenerator(iface)
+	gen.Backend = Backends["gomock"]
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `type Requester struct {
+	ctrl     *gomock.Controller
+	recorder *RequesterRecorder
+}
+
+type RequesterRecorder struct {
+	mock *Requester
+}
+
+func NewRequester(ctrl *gomock.Controller) *Requester {
+	m := &Requester{ctrl: ctrl}
+	m.recorder = &RequesterRecorder{m}
+	return m
+}
+
+func (m *Requester) EXPECT() *RequesterRecorder {
+	return m.recorder
+}
+func (m *Requester) Get(path string) (string, error) {
+	ret := m.ctrl.Call(m, "Get", path)
+	r0, _ := ret[0].(string)
+	r1, _ := ret[1].(error)
+	return r0, r1
+}
+func (mr *RequesterRecorder) Get(path interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*Requester)(nil).Get), path)
+}
+
+var _ test.Requester = (*Requester)(nil)
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+}
+
+func TestGomockGeneratorVarArg(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_vararg.go"))
+
+	iface, err := parser.Find("RequesterVarArg")
+
+	gen := NewGenerator(iface)
+	gen.Backend = Backends["gomock"]
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `type RequesterVarArg struct {
+	ctrl     *gomock.Controller
+	recorder *RequesterVarArgRecorder
+}
+
+type RequesterVarArgRecorder struct {
+	mock *RequesterVarArg
+}
+
+func NewRequesterVarArg(ctrl *gomock.Controller) *RequesterVarArg {
+	m := &RequesterVarArg{ctrl: ctrl}
+	m.recorder = &RequesterVarArgRecorder{m}
+	return m
+}
+
+func (m *RequesterVarArg) EXPECT() *RequesterVarArgRecorder {
+	return m.recorder
+}
+func (m *RequesterVarArg) Get(paths ...string) error {
+	_ca := []interface{}{}
+	for _, _va := range paths {
+		_ca = append(_ca, _va)
+	}
+	ret := m.ctrl.Call(m, "Get", _ca...)
+	r0, _ := ret[0].(error)
+	return r0
+}
+func (mr *RequesterVarArgRecorder) Get(paths ...interface{}) *gomock.Call {
+	_ca := append([]interface{}{}, paths...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*RequesterVarArg)(nil).Get), _ca...)
+}
+
+var _ test.RequesterVarArg = (*RequesterVarArg)(nil)
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+}
+
+func TestGomockGeneratorUnexported(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_unexported.go"))
+
+	iface, err := parser.Find("requester")
+
+	gen := NewGenerator(iface)
+	gen.Backend = Backends["gomock"]
+	gen.GenerateIPPrologue()
+
+	expected := `package test
+
+import "github.com/golang/mock/gomock"
+import "reflect"
+
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+	gen.buf.Reset()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	assert.Contains(t, gen.buf.String(), "func newMockRequester(ctrl *gomock.Controller) *mockRequester {\n")
+	assert.Contains(t, gen.buf.String(), "\nvar _ requester = (*mockRequester)(nil)\n")
+}
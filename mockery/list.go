@@ -0,0 +1,108 @@
+package mockery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+)
+
+// Listing describes an interface found for -list, along with its mock.
+type Listing struct {
+	Package  string `json:"package"`
+	Name     string `json:"name"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Exported bool   `json:"exported"`
+	Methods  int    `json:"methods"`
+	Mock     string `json:"mock"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// States of the mock of a Listing.
+const (
+	MockCurrent      = "current"
+	MockStale        = "stale"
+	MockMissing      = "missing"
+	MockNotGenerated = "not generated"
+	MockFailing      = "error"
+)
+
+// MockStatus reports the state of the mock at path by comparing it with
+// what render generates, which is only called for a file mockery wrote.
+// The error is why the mock could not be read or generated.
+func MockStatus(path string, render func() ([]byte, error)) (status string, err error) {
+	existing, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return MockMissing, nil
+	} else if err != nil {
+		return MockFailing, err
+	}
+
+	if !IsGenerated(existing) {
+		return MockNotGenerated, nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			status, err = MockFailing, fmt.Errorf("%v", r)
+		}
+	}()
+
+	src, err := render()
+	if err != nil {
+		return MockFailing, err
+	} else if !bytes.Equal(existing, src) {
+		return MockStale, nil
+	}
+
+	return MockCurrent, nil
+}
+
+// Listings are the interfaces found for -list.
+type Listings []Listing
+
+// WriteJSON writes l to w as an indented JSON array.
+func (l Listings) WriteJSON(w io.Writer) error {
+	if l == nil {
+		l = Listings{}
+	}
+
+	src, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(src, '\n'))
+	return err
+}
+
+// WriteText writes l to w as aligned columns, one interface to a line.
+func (l Listings) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, listing := range l {
+		exported := "exported"
+		if !listing.Exported {
+			exported = "unexported"
+		}
+
+		methods := fmt.Sprintf("%d methods", listing.Methods)
+		if listing.Methods == 1 {
+			methods = "1 method"
+		}
+
+		state := listing.Status
+		if listing.Error != "" {
+			state += ": " + listing.Error
+		}
+
+		fmt.Fprintf(tw, "%s.%s\t%s:%d\t%s\t%s\t%s (%s)\n",
+			listing.Package, listing.Name, listing.File, listing.Line, exported, methods, listing.Mock, state)
+	}
+
+	return tw.Flush()
+}
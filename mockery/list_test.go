@@ -0,0 +1,121 @@
+package mockery
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockery-list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mock := []byte(Header + "\n\npackage mocks\n")
+	path := filepath.Join(dir, "Store.go")
+
+	cases := []struct {
+		name     string
+		existing []byte
+		render   func() ([]byte, error)
+		status   string
+		err      string
+	}{
+		{"missing", nil, nil, MockMissing, ""},
+		{"current", mock, func() ([]byte, error) { return mock, nil }, MockCurrent, ""},
+		{"stale", mock, func() ([]byte, error) { return append(mock, '\n'), nil }, MockStale, ""},
+		{"not generated", []byte("package mocks\n"), nil, MockNotGenerated, ""},
+		{"failing", mock, func() ([]byte, error) { return nil, errors.New("boom") }, MockFailing, "boom"},
+		{"panicking", mock, func() ([]byte, error) { panic("boom") }, MockFailing, "boom"},
+	}
+
+	for _, c := range cases {
+		os.Remove(path)
+		if c.existing != nil {
+			if err := ioutil.WriteFile(path, c.existing, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		render := c.render
+		if render == nil {
+			render = func() ([]byte, error) {
+				t.Errorf("%s: rendered the mock", c.name)
+				return nil, nil
+			}
+		}
+
+		status, err := MockStatus(path, render)
+		assert.Equal(t, c.status, status, c.name)
+		if c.err == "" {
+			assert.NoError(t, err, c.name)
+		} else {
+			assert.EqualError(t, err, c.err, c.name)
+		}
+	}
+}
+
+func TestMockStatusUnreadable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockery-list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	status, err := MockStatus(dir, nil)
+	assert.Equal(t, MockFailing, status)
+	assert.Error(t, err)
+}
+
+var testListings = Listings{
+	{Package: "example.com/store", Name: "Store", File: "store.go", Line: 12, Exported: true, Methods: 3, Mock: "mocks/Store.go", Status: MockCurrent},
+	{Package: "example.com/store", Name: "cache", File: "cache.go", Line: 8, Methods: 1, Mock: "mocks/cache.go", Status: MockFailing, Error: "boom"},
+}
+
+func TestListingsWriteText(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := testListings.WriteText(&buf)
+	assert.NoError(t, err)
+
+	expected := "" +
+		"example.com/store.Store  store.go:12  exported    3 methods  mocks/Store.go (current)\n" +
+		"example.com/store.cache  cache.go:8   unexported  1 method   mocks/cache.go (error: boom)\n"
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestListingsWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := testListings[1:].WriteJSON(&buf)
+	assert.NoError(t, err)
+
+	expected := `[
+  {
+    "package": "example.com/store",
+    "name": "cache",
+    "file": "cache.go",
+    "line": 8,
+    "exported": false,
+    "methods": 1,
+    "mock": "mocks/cache.go",
+    "status": "error",
+    "error": "boom"
+  }
+]
+`
+
+	assert.Equal(t, expected, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, Listings(nil).WriteJSON(&buf))
+	assert.Equal(t, "[]\n", buf.String())
+}
@@ -2,14 +2,18 @@ package mockery
 
 import (
 	"go/ast"
+	"go/build/constraint"
 	"go/parser"
 	"go/token"
+	"os"
 	"path/filepath"
 )
 
 type Parser struct {
-	file *ast.File
-	path string
+	fset       *token.FileSet
+	file       *ast.File
+	path       string
+	constraint constraint.Expr
 }
 
 func NewParser() *Parser {
@@ -17,11 +21,28 @@ func NewParser() *Parser {
 }
 
 func (p *Parser) Parse(path string) error {
+	return p.parse(path, nil)
+}
+
+// ParseSource parses src as the contents of the file at path, which need
+// not exist, such as an unsaved editor buffer. Path still decides the
+// package the interfaces belong to.
+func (p *Parser) ParseSource(path string, src []byte) error {
+	return p.parse(path, src)
+}
+
+// parse parses the file at path, reading it unless src is a []byte.
+func (p *Parser) parse(path string, src interface{}) error {
 	fset := token.NewFileSet()
 
 	// Parse the file containing this very example
 	// but stop after processing the imports.
-	f, err := parser.ParseFile(fset, path, nil, 0)
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	expr, err := buildConstraint(f)
 	if err != nil {
 		return err
 	}
@@ -31,11 +52,47 @@ func (p *Parser) Parse(path string) error {
 		return err
 	}
 
+	p.fset = fset
 	p.path = abs
 	p.file = f
+	p.constraint = andConstraint(expr, fileNameConstraint(path))
 	return nil
 }
 
+// buildConstraint returns the build constraint of f, or nil if it has
+// none. A //go:build line takes precedence over // +build lines, which are
+// combined as the go command does.
+func buildConstraint(f *ast.File) (constraint.Expr, error) {
+	var plus constraint.Expr
+
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+
+		for _, c := range group.List {
+			if constraint.IsGoBuild(c.Text) {
+				return constraint.Parse(c.Text)
+			}
+
+			if constraint.IsPlusBuild(c.Text) {
+				expr, err := constraint.Parse(c.Text)
+				if err != nil {
+					return nil, err
+				}
+
+				if plus == nil {
+					plus = expr
+				} else {
+					plus = &constraint.AndExpr{X: plus, Y: expr}
+				}
+			}
+		}
+	}
+
+	return plus, nil
+}
+
 func (p *Parser) Find(name string) (*Interface, error) {
 	for _, decl := range p.file.Decls {
 		if gen, ok := decl.(*ast.GenDecl); ok {
@@ -43,7 +100,7 @@ func (p *Parser) Find(name string) (*Interface, error) {
 				if typespec, ok := spec.(*ast.TypeSpec); ok {
 					if typespec.Name.Name == name {
 						if iface, ok := typespec.Type.(*ast.InterfaceType); ok {
-							return &Interface{name, p.path, p.file, iface}, nil
+							return &Interface{name, p.path, p.file, iface, p.constraint, typeDirectives(gen, typespec), p.line(typespec)}, nil
 						} else {
 							return nil, ErrNotInterface
 						}
@@ -55,11 +112,84 @@ func (p *Parser) Find(name string) (*Interface, error) {
 	return nil, nil
 }
 
+// FindAfter returns the interface declared by the first type declaration
+// following line, as for a //go:generate directive on that line. It
+// returns nil if no type is declared after line and ErrNotInterface if
+// the type is not an interface.
+func (p *Parser) FindAfter(line int) (*Interface, error) {
+	for _, decl := range p.file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			typespec := spec.(*ast.TypeSpec)
+			if p.line(typespec) <= line {
+				continue
+			}
+
+			return p.Find(typespec.Name.Name)
+		}
+	}
+
+	return nil, nil
+}
+
 type Interface struct {
 	Name string
 	Path string
 	File *ast.File
 	Type *ast.InterfaceType
+
+	// Constraint is the build constraint of the file declaring the
+	// interface, including the one implied by its name, as for
+	// foo_linux.go, or nil if it has none.
+	Constraint constraint.Expr
+
+	// Directives are the //mockery: comments documenting the interface.
+	Directives Directives
+
+	// Line is the line of Path declaring the interface.
+	Line int
+}
+
+// ImportPath returns the import path of the package declaring the
+// interface, which has to be in GOPATH.
+func (iface *Interface) ImportPath() (string, error) {
+	rel, err := filepath.Rel(filepath.Join(os.Getenv("GOPATH"), "src"), filepath.Dir(iface.Path))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// NumMethods returns the number of methods the interface declares, leaving
+// out the interfaces it embeds.
+func (iface *Interface) NumMethods() int {
+	n := 0
+	for _, field := range iface.Type.Methods.List {
+		if _, ok := field.Type.(*ast.FuncType); ok {
+			n += len(field.Names)
+		}
+	}
+
+	return n
+}
+
+func (p *Parser) line(node ast.Node) int {
+	return p.fset.Position(node.Pos()).Line
+}
+
+// typeDirectives returns the directives documenting spec, which are in the
+// doc comment of its declaration unless that groups several types.
+func typeDirectives(gen *ast.GenDecl, spec *ast.TypeSpec) Directives {
+	if spec.Doc == nil && len(gen.Specs) == 1 {
+		return ParseDirectives(gen.Doc)
+	}
+
+	return ParseDirectives(spec.Doc)
 }
 
 func (p *Parser) Interfaces() []*Interface {
@@ -70,7 +200,7 @@ func (p *Parser) Interfaces() []*Interface {
 			for _, spec := range gen.Specs {
 				if typespec, ok := spec.(*ast.TypeSpec); ok {
 					if iface, ok := typespec.Type.(*ast.InterfaceType); ok {
-						ifaces = append(ifaces, &Interface{typespec.Name.Name, p.path, p.file, iface})
+						ifaces = append(ifaces, &Interface{typespec.Name.Name, p.path, p.file, iface, p.constraint, typeDirectives(gen, typespec), p.line(typespec)})
 					}
 				}
 			}
@@ -45,3 +45,101 @@ func TestFileInterfaces(t *testing.T) {
 	assert.Equal(t, 1, len(nodes))
 	assert.Equal(t, "Requester", nodes[0].Name)
 }
+
+func TestFileParseSource(t *testing.T) {
+	parser := NewParser()
+
+	path := filepath.Join(fixturePath, "unsaved.go")
+	err := parser.ParseSource(path, []byte("package test\n\ntype Unsaved interface {\n\tDo()\n}\n"))
+	assert.NoError(t, err)
+
+	iface, err := parser.Find("Unsaved")
+	assert.NoError(t, err)
+	assert.Equal(t, path, iface.Path)
+	assert.Equal(t, 3, iface.Line)
+
+	iface, err = parser.Find("Requester")
+	assert.NoError(t, err)
+	assert.Nil(t, iface)
+}
+
+func TestInterfaceImportPath(t *testing.T) {
+	parser := NewParser()
+
+	err := parser.Parse(testFile)
+	assert.NoError(t, err)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	path, err := iface.ImportPath()
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/ryanbrainard/mockery/mockery/fixtures", path)
+}
+
+func TestInterfaceNumMethods(t *testing.T) {
+	parser := NewParser()
+
+	err := parser.ParseSource("/src/store/store.go", []byte("package store\n\nimport \"io\"\n\ntype Store interface {\n\tio.Closer\n\tGet(key string) string\n\tPut(key, value string)\n}\n"))
+	assert.NoError(t, err)
+
+	iface, err := parser.Find("Store")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, iface.NumMethods())
+}
+
+func TestFileBuildConstraint(t *testing.T) {
+	parser := NewParser()
+
+	err := parser.Parse(filepath.Join(fixturePath, "requester_constrained.go"))
+	assert.NoError(t, err)
+
+	iface, err := parser.Find("RequesterConstrained")
+	assert.NoError(t, err)
+	if assert.NotNil(t, iface.Constraint) {
+		assert.Equal(t, "linux || darwin", iface.Constraint.String())
+	}
+
+	err = parser.Parse(testFile)
+	assert.NoError(t, err)
+
+	iface, err = parser.Find("Requester")
+	assert.NoError(t, err)
+	assert.Nil(t, iface.Constraint)
+}
+
+func TestFileDirectives(t *testing.T) {
+	parser := NewParser()
+
+	err := parser.Parse(filepath.Join(fixturePath, "requester_annotated.go"))
+	assert.NoError(t, err)
+
+	iface, err := parser.Find("RequesterAnnotated")
+	assert.NoError(t, err)
+	assert.Equal(t, Directives{"generate": "", "name": "FakeRequester"}, iface.Directives)
+
+	iface, err = parser.Find("RequesterSkipped")
+	assert.NoError(t, err)
+	assert.True(t, iface.Directives.Has(DirectiveSkip))
+	assert.False(t, iface.Directives.Has(DirectiveGenerate))
+}
+
+func TestFileFindAfter(t *testing.T) {
+	parser := NewParser()
+
+	err := parser.Parse(filepath.Join(fixturePath, "requester_generate.go"))
+	assert.NoError(t, err)
+
+	iface, err := parser.FindAfter(3)
+	assert.NoError(t, err)
+	if assert.NotNil(t, iface) {
+		assert.Equal(t, "RequesterGenerate", iface.Name)
+	}
+
+	_, err = parser.FindAfter(9)
+	assert.Equal(t, ErrNotInterface, err)
+
+	iface, err = parser.FindAfter(11)
+	assert.NoError(t, err)
+	assert.Nil(t, iface)
+}
@@ -0,0 +1,329 @@
+package mockery
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Layouts of the mocks written outside the package of their interface.
+const (
+	// LayoutFlat writes every mock to Output, in package mocks.
+	LayoutFlat = "flat"
+	// LayoutMirror writes the mocks of each package to the directory
+	// under Output mirroring its place under Root, in package mock<pkg>.
+	LayoutMirror = "mirror"
+	// LayoutColocated writes the mocks of each package to a mocks
+	// directory next to it.
+	LayoutColocated = "colocated"
+)
+
+// FileNamer names the files mocks are written to and the packages they
+// are declared in.
+type FileNamer struct {
+	Output string // directory of mocks with LayoutFlat and LayoutMirror
+	Root   string // directory searched for interfaces, which LayoutMirror mirrors
+	Layout string // one of the Layout constants, LayoutFlat if empty
+
+	InPackage  bool // write every mock to the package of its interface, as DirectiveInPackage does for one
+	Group      bool // write the mocks of a package built under the same constraints to a single file
+	Aggregate  bool // group mocks, naming files after packages rather than interfaces
+	TestOnly   bool // write mocks in the package of their interface to _test.go files
+	XTest      bool // write mocks to the external test package of their interface
+	Underscore bool // name files in snake case rather than camel case
+
+	// Printed keeps the package of LayoutFlat mocks mocks instead of
+	// naming it after Output, which they are not written to.
+	Printed bool
+}
+
+var caseBoundary = regexp.MustCompile("(.)([A-Z])")
+
+// File returns the path of the file to write the mock of iface to and the
+// package to declare it in. With ip, the mock is written to the package of
+// iface.
+func (n *FileNamer) File(iface *Interface, ip bool) (path, pkg string) {
+	dir := filepath.Dir(iface.Path)
+	src := iface.File.Name.Name
+
+	name := iface.Name
+	if custom := iface.Directives[DirectiveName]; custom != "" {
+		name = custom
+	}
+	if n.Underscore {
+		name = strings.ToLower(caseBoundary.ReplaceAllString(name, "${1}_${2}"))
+	}
+
+	// Aggregated mocks only share a file with those built under the same
+	// constraints, so those declared under one get a file of their own.
+	constrained := n.Aggregate && iface.Constraint != nil
+
+	if ip || n.XTest {
+		base := "mock_" + name
+		if constrained {
+			base = constrainedBase("mocks", iface)
+		} else if n.Aggregate {
+			base = "mocks"
+		}
+		if n.TestOnly || n.XTest {
+			base += "_test"
+		}
+
+		pkg = src
+		if !ip {
+			pkg += "_test"
+		}
+		return filepath.Join(dir, base+".go"), pkg
+	}
+
+	if constrained {
+		name = constrainedBase(src, iface)
+	} else if n.Aggregate {
+		name = src
+	}
+
+	out := n.OutputDir(dir)
+	switch {
+	case n.Layout == LayoutMirror:
+		pkg = "mock" + src
+	case n.Layout == LayoutColocated || n.Printed:
+		pkg = "mocks"
+	default:
+		pkg = filepath.Base(out)
+	}
+
+	return filepath.Join(out, name+".go"), pkg
+}
+
+// constrainedBase names the file holding the aggregated mocks of iface,
+// which is declared under a build constraint, after prefix and the file
+// declaring iface, which no interface under another constraint shares. A
+// name implying more of the constraint than that file does, as p_linux
+// does for linux.go, is suffixed to imply none, leaving it to the build
+// constraint of the mock.
+func constrainedBase(prefix string, iface *Interface) string {
+	src := strings.TrimSuffix(filepath.Base(iface.Path), ".go")
+	base := prefix + "_" + src
+
+	implied := func(name string) string {
+		if expr := fileNameConstraint(name + ".go"); expr != nil {
+			return expr.String()
+		}
+		return ""
+	}
+	if implied(base) != implied(src) {
+		base += "_mocks"
+	}
+
+	return base
+}
+
+// OutputDir returns the directory to write the mocks of the package in
+// dir to when they are not written to it, according to Layout.
+func (n *FileNamer) OutputDir(dir string) string {
+	switch n.Layout {
+	case LayoutMirror:
+		root, err := filepath.Abs(n.Root)
+		if err != nil {
+			root = n.Root
+		}
+
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = filepath.Base(dir)
+		}
+
+		return filepath.Join(n.Output, rel)
+	case LayoutColocated:
+		return filepath.Join(dir, "mocks")
+	}
+
+	return n.Output
+}
+
+// pathFlags take paths, which GenerateCommand makes relative to the
+// directory of the directive.
+var pathFlags = map[string]bool{
+	"template":  true,
+	"cache-dir": true,
+}
+
+// GenerateCommand returns the mockery command for a //go:generate
+// directive in dir, repeating flags, those given that control how mocks
+// are generated, followed by -output unless the mocks are written next to
+// their interfaces. Paths, given relative to the working directory, are
+// made relative to dir, where go generate runs mockery.
+func (n *FileNamer) GenerateCommand(dir string, flags []*flag.Flag) string {
+	args := []string{"mockery"}
+
+	for _, f := range flags {
+		value := f.Value.String()
+		if pathFlags[f.Name] && value != "" {
+			value = relativeTo(dir, value)
+		}
+
+		if b, ok := f.Value.(interface {
+			IsBoolFlag() bool
+		}); ok && b.IsBoolFlag() {
+			if value == "true" {
+				args = append(args, "-"+f.Name)
+			} else {
+				args = append(args, "-"+f.Name+"="+value)
+			}
+			continue
+		}
+
+		args = append(args, "-"+f.Name, quoteArg(value))
+	}
+
+	if !n.InPackage && !n.XTest && n.Layout != LayoutColocated {
+		output := n.Output
+		if n.Layout == LayoutMirror {
+			output = n.OutputDir(absPath(dir))
+		}
+		args = append(args, "-output", quoteArg(relativeTo(dir, output)))
+	}
+
+	return strings.Join(args, " ")
+}
+
+// relativeTo returns path, given relative to the working directory,
+// relative to dir instead.
+func relativeTo(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	rel, err := filepath.Rel(absPath(dir), absPath(path))
+	if err != nil {
+		return path
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return path
+}
+
+// quoteArg quotes arg as go generate expects, if it needs to be.
+func quoteArg(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t\"") {
+		return strconv.Quote(arg)
+	}
+
+	return arg
+}
+
+// MockFile is a file to generate, holding the mocks of one or more
+// interfaces declared in the same package.
+type MockFile struct {
+	Path       string
+	Package    string
+	InPackage  bool
+	Interfaces []*Interface
+
+	// Err is why the file can not be generated, such as another file
+	// taking its path or the names of its mocks.
+	Err error
+}
+
+// Names lists the names of the interfaces of f.
+func (f *MockFile) Names() string {
+	var names []string
+	for _, iface := range f.Interfaces {
+		names = append(names, iface.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Plan decides which files to generate for ifaces, honouring the name and
+// inpkg directives of each. With Group or Aggregate, the mocks of the
+// interfaces of a package built under the same constraints share a single
+// file. Files that would hold the mocks of different packages, or declare
+// mocks of the same name in one package, are returned with an Err rather
+// than stopping the others.
+func (n *FileNamer) Plan(ifaces []*Interface) []*MockFile {
+	var files []*MockFile
+	byKey := map[string]*MockFile{}
+	byPath := map[string]*MockFile{}
+
+	for _, iface := range ifaces {
+		ip := n.InPackage || iface.Directives.Has(DirectiveInPackage)
+
+		key := fmt.Sprintf("%s:%t:", filepath.Dir(iface.Path), ip)
+		if iface.Constraint != nil {
+			key += iface.Constraint.String()
+		}
+
+		if n.Group || n.Aggregate {
+			if file, ok := byKey[key]; ok {
+				file.Interfaces = append(file.Interfaces, iface)
+				continue
+			}
+		}
+
+		path, pkg := n.File(iface, ip)
+		file := &MockFile{Path: path, Package: pkg, InPackage: ip, Interfaces: []*Interface{iface}}
+
+		if other, ok := byPath[path]; ok {
+			n.clash(other, file, fmt.Errorf("%s would hold the mocks of both %s and %s (use -layout to separate them)",
+				path, n.describe(other.Interfaces[0]), n.describe(iface)))
+		}
+
+		files = append(files, file)
+		byKey[key] = file
+		byPath[path] = file
+	}
+
+	// Files in one package share their names, so no two mocks written to
+	// the same package can be named alike.
+	byName := map[string]*MockFile{}
+	named := map[string]*Interface{}
+	for _, file := range files {
+		for _, iface := range file.Interfaces {
+			name := MockName(iface, file.InPackage)
+			key := filepath.Dir(file.Path) + ":" + file.Package + ":" + name
+
+			if other, ok := byName[key]; ok {
+				n.clash(other, file, fmt.Errorf("the mocks of both %s and %s would be named %s in %s (use -layout to separate them)",
+					n.describe(named[key]), n.describe(iface), name, filepath.Dir(file.Path)))
+				continue
+			}
+			byName[key] = file
+			named[key] = iface
+		}
+	}
+
+	return files
+}
+
+// clash records err on both files, unless they already have an error.
+func (n *FileNamer) clash(a, b *MockFile, err error) {
+	for _, file := range []*MockFile{a, b} {
+		if file.Err == nil {
+			file.Err = err
+		}
+	}
+}
+
+// describe names iface along with the directory declaring it, relative to
+// Root where possible.
+func (n *FileNamer) describe(iface *Interface) string {
+	path := filepath.Join(filepath.Dir(iface.Path), iface.Name)
+
+	if root, err := filepath.Abs(n.Root); err == nil {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+
+	return path
+}
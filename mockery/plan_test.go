@@ -0,0 +1,237 @@
+package mockery
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileNamer(t *testing.T) {
+	parser := NewParser()
+
+	err := parser.ParseSource("/src/store/store.go", []byte("package store\n\ntype RemoteStore interface {\n\tGet() string\n}\n"))
+	assert.NoError(t, err)
+	iface, err := parser.Find("RemoteStore")
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		namer FileNamer
+		ip    bool
+		path  string
+		pkg   string
+	}{
+		{"flat", FileNamer{Output: "/mocks"}, false, "/mocks/RemoteStore.go", "mocks"},
+		{"flat printed", FileNamer{Output: "/out", Printed: true}, false, "/out/RemoteStore.go", "mocks"},
+		{"flat output", FileNamer{Output: "/out"}, false, "/out/RemoteStore.go", "out"},
+		{"underscore", FileNamer{Output: "/mocks", Underscore: true}, false, "/mocks/remote_store.go", "mocks"},
+		{"aggregate", FileNamer{Output: "/mocks", Aggregate: true}, false, "/mocks/store.go", "mocks"},
+		{"mirror", FileNamer{Output: "/mocks", Root: "/src", Layout: LayoutMirror}, false, "/mocks/store/RemoteStore.go", "mockstore"},
+		{"mirror outside root", FileNamer{Output: "/mocks", Root: "/other", Layout: LayoutMirror}, false, "/mocks/store/RemoteStore.go", "mockstore"},
+		{"colocated", FileNamer{Output: "/out", Layout: LayoutColocated}, false, "/src/store/mocks/RemoteStore.go", "mocks"},
+		{"inpkg", FileNamer{Output: "/mocks"}, true, "/src/store/mock_RemoteStore.go", "store"},
+		{"inpkg aggregate", FileNamer{Aggregate: true}, true, "/src/store/mocks.go", "store"},
+		{"testonly", FileNamer{TestOnly: true}, true, "/src/store/mock_RemoteStore_test.go", "store"},
+		{"testonly aggregate", FileNamer{TestOnly: true, Aggregate: true}, true, "/src/store/mocks_test.go", "store"},
+		{"testonly underscore", FileNamer{TestOnly: true, Underscore: true}, true, "/src/store/mock_remote_store_test.go", "store"},
+		{"xtest", FileNamer{Output: "/mocks", XTest: true}, false, "/src/store/mock_RemoteStore_test.go", "store_test"},
+		{"xtest aggregate", FileNamer{XTest: true, Aggregate: true}, false, "/src/store/mocks_test.go", "store_test"},
+		{"xtest inpkg", FileNamer{XTest: true}, true, "/src/store/mock_RemoteStore_test.go", "store"},
+	}
+
+	for _, c := range cases {
+		path, pkg := c.namer.File(iface, c.ip)
+		assert.Equal(t, filepath.FromSlash(c.path), path, c.name)
+		assert.Equal(t, c.pkg, pkg, c.name)
+	}
+}
+
+func TestFileNamerDirective(t *testing.T) {
+	parser := NewParser()
+
+	err := parser.ParseSource("/src/store/store.go", []byte("package store\n\n//mockery:name=FakeStore\ntype Store interface {\n\tGet() string\n}\n"))
+	assert.NoError(t, err)
+	iface, err := parser.Find("Store")
+	assert.NoError(t, err)
+
+	namer := &FileNamer{Output: "/mocks", Underscore: true}
+
+	path, _ := namer.File(iface, false)
+	assert.Equal(t, filepath.FromSlash("/mocks/fake_store.go"), path)
+
+	path, _ = namer.File(iface, true)
+	assert.Equal(t, filepath.FromSlash("/src/store/mock_fake_store.go"), path)
+}
+
+func TestFileNamerGenerateCommand(t *testing.T) {
+	dir := filepath.FromSlash("sub/pkg")
+
+	cases := []struct {
+		name    string
+		namer   FileNamer
+		args    []string
+		command string
+	}{
+		{"output", FileNamer{Output: "./mocks"}, nil, "mockery -output ../../mocks"},
+		{"absolute output", FileNamer{Output: "/mocks"}, nil, "mockery -output /mocks"},
+		{"bool", FileNamer{Output: "mocks"}, []string{"-lenient", "-inorder=false"}, "mockery -inorder=false -lenient -output ../../mocks"},
+		{"quoted", FileNamer{Output: "mocks"}, []string{"-note", "a \"b\"", "-tags", ""}, `mockery -note "a \"b\"" -tags "" -output ../../mocks`},
+		{"template", FileNamer{Output: "mocks"}, []string{"-template", "tmpl/mock.tmpl"}, "mockery -template ../../tmpl/mock.tmpl -output ../../mocks"},
+		{"absolute template", FileNamer{Output: "mocks"}, []string{"-template", "/tmpl/mock.tmpl"}, "mockery -template /tmpl/mock.tmpl -output ../../mocks"},
+		{"cache dir", FileNamer{Output: "mocks"}, []string{"-cache-dir", ".cache"}, "mockery -cache-dir ../../.cache -output ../../mocks"},
+		{"empty cache dir", FileNamer{Output: "mocks"}, []string{"-cache-dir="}, `mockery -cache-dir "" -output ../../mocks`},
+		{"mirror", FileNamer{Output: "mocks", Root: ".", Layout: LayoutMirror}, nil, "mockery -output ../../mocks/sub/pkg"},
+		{"colocated", FileNamer{Output: "mocks", Layout: LayoutColocated}, nil, "mockery"},
+		{"inpkg", FileNamer{Output: "mocks", InPackage: true}, []string{"-inpkg"}, "mockery -inpkg"},
+		{"xtest", FileNamer{Output: "mocks", XTest: true}, []string{"-xtest"}, "mockery -xtest"},
+	}
+
+	for _, c := range cases {
+		set := flag.NewFlagSet("mockery", flag.ContinueOnError)
+		set.Bool("lenient", false, "")
+		set.Bool("inorder", false, "")
+		set.Bool("inpkg", false, "")
+		set.Bool("xtest", false, "")
+		set.String("note", "", "")
+		set.String("tags", "", "")
+		set.String("template", "", "")
+		set.String("cache-dir", "", "")
+
+		if err := set.Parse(c.args); err != nil {
+			t.Fatal(err)
+		}
+
+		var flags []*flag.Flag
+		set.Visit(func(f *flag.Flag) {
+			flags = append(flags, f)
+		})
+
+		assert.Equal(t, c.command, c.namer.GenerateCommand(dir, flags), c.name)
+	}
+}
+
+// planInterfaces parses sources, each a path and its source, and returns
+// the interfaces they declare, in order.
+func planInterfaces(t *testing.T, sources ...[2]string) []*Interface {
+	var ifaces []*Interface
+
+	for _, source := range sources {
+		parser := NewParser()
+
+		err := parser.ParseSource(source[0], []byte(source[1]))
+		assert.NoError(t, err)
+
+		ifaces = append(ifaces, parser.Interfaces()...)
+	}
+
+	return ifaces
+}
+
+func TestFileNamerPlanClash(t *testing.T) {
+	ifaces := planInterfaces(t,
+		[2]string{"/src/a/store.go", "package a\n\ntype Store interface {\n\tGet() string\n}\n"},
+		[2]string{"/src/b/store.go", "package b\n\ntype Store interface {\n\tGet() string\n}\n"},
+		[2]string{"/src/b/cache.go", "package b\n\ntype Cache interface {\n\tGet() string\n}\n"},
+	)
+
+	files := (&FileNamer{Output: "/mocks", Root: "/src"}).Plan(ifaces)
+	if assert.Len(t, files, 3) {
+		assert.EqualError(t, files[0].Err, filepath.FromSlash("/mocks/Store.go would hold the mocks of both a/Store and b/Store (use -layout to separate them)"))
+		assert.Equal(t, files[0].Err, files[1].Err)
+		assert.NoError(t, files[2].Err)
+	}
+
+	files = (&FileNamer{Output: "/mocks", Root: "/src", Layout: LayoutMirror}).Plan(ifaces)
+	for _, file := range files {
+		assert.NoError(t, file.Err)
+	}
+}
+
+func TestFileNamerPlanNameClash(t *testing.T) {
+	ifaces := planInterfaces(t,
+		[2]string{"/src/a/store.go", "package a\n\n//mockery:name=Fake\ntype Store interface {\n\tGet() string\n}\n"},
+		[2]string{"/src/a/cache.go", "package a\n\n//mockery:name=Fake\ntype Cache interface {\n\tGet() string\n}\n"},
+	)
+
+	files := (&FileNamer{Output: "/mocks", Root: "/src", Aggregate: true}).Plan(ifaces)
+	if assert.Len(t, files, 1) {
+		assert.EqualError(t, files[0].Err, "the mocks of both a/Store and a/Cache would be named Fake in "+filepath.FromSlash("/mocks")+" (use -layout to separate them)")
+	}
+
+	ifaces = planInterfaces(t,
+		[2]string{"/src/a/store.go", "package a\n\ntype Store interface {\n\tGet() string\n}\n"},
+		[2]string{"/src/a/cache.go", "package a\n\n//mockery:name=MockStore\ntype Cache interface {\n\tGet() string\n}\n"},
+		[2]string{"/src/a/conn.go", "package a\n\ntype Conn interface {\n\tClose() error\n}\n"},
+	)
+
+	files = (&FileNamer{Root: "/src", InPackage: true}).Plan(ifaces)
+	if assert.Len(t, files, 3) {
+		assert.EqualError(t, files[0].Err, "the mocks of both a/Store and a/Cache would be named MockStore in "+filepath.FromSlash("/src/a")+" (use -layout to separate them)")
+		assert.Equal(t, files[0].Err, files[1].Err)
+		assert.NoError(t, files[2].Err)
+	}
+}
+
+func TestFileNamerPlanConstraints(t *testing.T) {
+	ifaces := planInterfaces(t,
+		[2]string{"/src/p/bar.go", "package p\n\ntype Bar interface {\n\tGet() string\n}\n"},
+		[2]string{"/src/p/foo_linux.go", "package p\n\ntype Foo interface {\n\tGet() string\n}\n"},
+		[2]string{"/src/p/cgo.go", "//go:build cgo\n\npackage p\n\ntype Cgo interface {\n\tGet() string\n}\n"},
+		[2]string{"/src/p/baz.go", "package p\n\ntype Baz interface {\n\tGet() string\n}\n"},
+		[2]string{"/src/p/more_cgo.go", "//go:build cgo\n\npackage p\n\ntype MoreCgo interface {\n\tGet() string\n}\n"},
+		[2]string{"/src/p/linux_amd64.go", "package p\n\ntype Arch interface {\n\tGet() string\n}\n"},
+	)
+
+	type plan struct {
+		path  string
+		names string
+		build string
+	}
+
+	cases := []struct {
+		name  string
+		namer FileNamer
+		files []plan
+	}{
+		{"aggregate", FileNamer{Output: "/mocks", Aggregate: true}, []plan{
+			{"/mocks/p.go", "Bar, Baz", ""},
+			{"/mocks/p_foo_linux.go", "Foo", "//go:build linux"},
+			{"/mocks/p_cgo.go", "Cgo, MoreCgo", "//go:build cgo"},
+			{"/mocks/p_linux_amd64_mocks.go", "Arch", "//go:build amd64"},
+		}},
+		{"aggregate inpkg", FileNamer{InPackage: true, Aggregate: true}, []plan{
+			{"/src/p/mocks.go", "Bar, Baz", ""},
+			{"/src/p/mocks_foo_linux.go", "Foo", "//go:build linux"},
+			{"/src/p/mocks_cgo.go", "Cgo, MoreCgo", "//go:build cgo"},
+			{"/src/p/mocks_linux_amd64_mocks.go", "Arch", "//go:build amd64"},
+		}},
+		{"grouped", FileNamer{Output: "/mocks", Group: true, Printed: true}, []plan{
+			{"/mocks/Bar.go", "Bar, Baz", ""},
+			{"/mocks/Foo.go", "Foo", "//go:build linux"},
+			{"/mocks/Cgo.go", "Cgo, MoreCgo", "//go:build cgo"},
+			{"/mocks/Arch.go", "Arch", "//go:build amd64"},
+		}},
+	}
+
+	for _, c := range cases {
+		var files []plan
+		for _, file := range c.namer.Plan(ifaces) {
+			assert.NoError(t, file.Err, c.name)
+
+			gen := NewMultiGenerator(file.Interfaces)
+			gen.ImportPath = "example.com/p"
+			gen.GeneratePrologue("mocks")
+			build := strings.SplitN(gen.buf.String(), "\n", 2)[0]
+			if !strings.HasPrefix(build, "//go:build") {
+				build = ""
+			}
+
+			files = append(files, plan{filepath.ToSlash(file.Path), file.Names(), build})
+		}
+
+		assert.Equal(t, c.files, files, c.name)
+	}
+}
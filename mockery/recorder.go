@@ -0,0 +1,93 @@
+package mockery
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// RecordingPackage is the package the recorders generated by the recorder
+// backend record calls with.
+const RecordingPackage = "github.com/ryanbrainard/mockery/recording"
+
+// recorderBackend renders a recorder rather than a mock: it passes each
+// call on to a real implementation of the interface and records the call,
+// its results and how long it took, so the calls can be replayed on a
+// testify mock later.
+type recorderBackend struct{}
+
+func (recorderBackend) Imports(g *Generator) []string {
+	return []string{"time", RecordingPackage}
+}
+
+func (recorderBackend) Generate(g *Generator, iface *Interface) error {
+	name := g.mockName(iface)
+
+	g.printf("// %s passes calls on to Real, recording each of them to Sink, or\n", name)
+	g.printf("// to recording.DefaultSink if it is nil.\n")
+	g.printf("type %s struct {\n", name)
+	if g.ip || ast.IsExported(iface.Name) {
+		g.printf("\tReal %s\n", g.interfaceType(iface))
+	} else {
+		// The interface can not be named outside its package, so Real
+		// repeats its methods.
+		g.printf("\tReal interface {\n")
+		for _, method := range append(g.methods(iface), g.skippedMethods(iface)...) {
+			ftype := method.Type.(*ast.FuncType)
+			_, _, params, _ := g.genList(iface, ftype.Params, true)
+			_, _, returns, _ := g.genList(iface, ftype.Results, false)
+			g.printf("\t\t%s(%s)%s\n", method.Names[0].Name, strings.Join(params, ", "), resultList(returns))
+		}
+		g.printf("\t}\n")
+	}
+	g.printf("\tSink recording.Sink\n")
+	g.printf("}\n")
+
+	for _, method := range g.methods(iface) {
+		ftype := method.Type.(*ast.FuncType)
+		fname := method.Names[0].Name
+
+		paramNames, _, params, args := g.genList(iface, ftype.Params, true)
+		_, _, returns, _ := g.genList(iface, ftype.Results, false)
+
+		var results []string
+		for idx := range returns {
+			results = append(results, fmt.Sprintf("_r%d", idx))
+		}
+
+		g.printf("\nfunc (m *%s) %s(%s)%s {\n", name, fname, strings.Join(params, ", "), resultList(returns))
+		g.printf("\t_start := time.Now()\n")
+		if len(results) > 0 {
+			g.printf("\t%s := m.Real.%s(%s)\n", strings.Join(results, ", "), fname, strings.Join(args, ", "))
+		} else {
+			g.printf("\tm.Real.%s(%s)\n", fname, strings.Join(args, ", "))
+		}
+		g.printf("\trecording.Record(m.Sink, %q, %q, time.Since(_start), []interface{}{%s}, []interface{}{%s})\n",
+			iface.Name, fname, strings.Join(paramNames, ", "), strings.Join(results, ", "))
+		if len(results) > 0 {
+			g.printf("\n\treturn %s\n", strings.Join(results, ", "))
+		}
+		g.printf("}\n")
+	}
+
+	// Skipped methods are left out of the recording, but a recorder still
+	// has to pass them on rather than fail the code it wraps.
+	for _, method := range g.skippedMethods(iface) {
+		ftype := method.Type.(*ast.FuncType)
+		fname := method.Names[0].Name
+
+		_, _, params, args := g.genList(iface, ftype.Params, true)
+		_, _, returns, _ := g.genList(iface, ftype.Results, false)
+
+		g.printf("\nfunc (m *%s) %s(%s)%s {\n", name, fname, strings.Join(params, ", "), resultList(returns))
+		if len(returns) > 0 {
+			g.printf("\treturn m.Real.%s(%s)\n", fname, strings.Join(args, ", "))
+		} else {
+			g.printf("\tm.Real.%s(%s)\n", fname, strings.Join(args, ", "))
+		}
+		g.printf("}\n")
+	}
+
+	g.generateAssertion(iface)
+	return nil
+}
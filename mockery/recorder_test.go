@@ -0,0 +1,117 @@
+package mockery
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorderGenerator(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+
+	gen := NewGenerator(iface)
+	gen.Backend = Backends["recorder"]
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `// Requester passes calls on to Real, recording each of them to Sink, or
+// to recording.DefaultSink if it is nil.
+type Requester struct {
+	Real test.Requester
+	Sink recording.Sink
+}
+
+func (m *Requester) Get(path string) (string, error) {
+	_start := time.Now()
+	_r0, _r1 := m.Real.Get(path)
+	recording.Record(m.Sink, "Requester", "Get", time.Since(_start), []interface{}{path}, []interface{}{_r0, _r1})
+
+	return _r0, _r1
+}
+
+var _ test.Requester = (*Requester)(nil)
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+}
+
+func TestRecorderGeneratorVarArgs(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester4.go"))
+
+	iface, err := parser.Find("Requester4")
+
+	gen := NewGenerator(iface)
+	gen.Backend = Backends["recorder"]
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	assert.Contains(t, gen.buf.String(), `	_start := time.Now()
+	m.Real.Get()
+	recording.Record(m.Sink, "Requester4", "Get", time.Since(_start), []interface{}{}, []interface{}{})
+}`)
+
+	parser.Parse(filepath.Join(fixturePath, "requester_vararg.go"))
+
+	iface, err = parser.Find("RequesterVarArg")
+
+	gen = NewGenerator(iface)
+	gen.Backend = Backends["recorder"]
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	assert.Contains(t, gen.buf.String(), `	_r0 := m.Real.Get(paths...)
+	recording.Record(m.Sink, "RequesterVarArg", "Get", time.Since(_start), []interface{}{paths}, []interface{}{_r0})`)
+}
+
+func TestRecorderGeneratorUnexported(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_unexported.go"))
+
+	iface, err := parser.Find("requester")
+
+	gen := NewGenerator(iface)
+	gen.Backend = Backends["recorder"]
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	assert.Contains(t, gen.buf.String(), `type requester struct {
+	Real interface {
+		Get()
+	}
+	Sink recording.Sink
+}`)
+}
+
+func TestRecorderGeneratorSkipped(t *testing.T) {
+	parser := NewParser()
+
+	err := parser.ParseSource("/src/store/store.go", []byte("package store\n\ntype store interface {\n\tGet(key string) string\n\n\t//mockery:skip\n\tClose()\n}\n"))
+	assert.NoError(t, err)
+	iface, err := parser.Find("store")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.Backend = Backends["recorder"]
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	assert.Contains(t, gen.buf.String(), `type store struct {
+	Real interface {
+		Get(key string) string
+		Close()
+	}
+	Sink recording.Sink
+}`)
+	assert.Contains(t, gen.buf.String(), "func (m *store) Close() {\n\tm.Real.Close()\n}\n")
+	assert.NotContains(t, gen.buf.String(), `"Close"`)
+}
@@ -0,0 +1,170 @@
+package mockery
+
+import (
+	"encoding/json"
+	"go/scanner"
+	"io"
+	"path/filepath"
+)
+
+// Report describes what a run of mockery did, for tools to consume
+// instead of its output.
+type Report struct {
+	// Files lists the files and directories searched for interfaces.
+	Files []ReportFile `json:"files"`
+
+	// Interfaces lists the interfaces selected for mocking.
+	Interfaces []ReportInterface `json:"interfaces"`
+
+	// Outputs lists the files mocks were generated into.
+	Outputs []ReportOutput `json:"outputs"`
+
+	// Errors lists what went wrong generating the mocks.
+	Errors []ReportError `json:"errors"`
+}
+
+// ReportFile is a file or directory searched for interfaces.
+type ReportFile struct {
+	Path string `json:"path"`
+
+	// Skipped is the reason the file was left out, or empty if it was
+	// parsed.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// ReportInterface is an interface selected for mocking.
+type ReportInterface struct {
+	Name    string `json:"name"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Methods int    `json:"methods"`
+}
+
+// Statuses of a ReportOutput.
+const (
+	OutputWritten   = "written"
+	OutputUnchanged = "unchanged"
+	OutputSkipped   = "skipped"
+)
+
+// ReportOutput is a file generated for one or more interfaces.
+type ReportOutput struct {
+	Path       string   `json:"path"`
+	Status     string   `json:"status"`
+	Interfaces []string `json:"interfaces"`
+}
+
+// ReportError is an error generating the mocks of one or more interfaces,
+// or reading a file searched for them. The position is that of the type
+// error in the mock for a VerifyError, that of the syntax error for a file
+// that could not be parsed, and the declaration of the first interface
+// otherwise.
+type ReportError struct {
+	Interfaces []string `json:"interfaces,omitempty"`
+	File       string   `json:"file"`
+	Line       int      `json:"line"`
+	Column     int      `json:"column,omitempty"`
+	Message    string   `json:"message"`
+}
+
+// NewReport returns an empty Report, which lists nothing rather than null
+// in JSON.
+func NewReport() *Report {
+	return &Report{
+		Files:      []ReportFile{},
+		Interfaces: []ReportInterface{},
+		Outputs:    []ReportOutput{},
+		Errors:     []ReportError{},
+	}
+}
+
+// AddInterface records iface as selected for mocking.
+func (r *Report) AddInterface(iface *Interface) {
+	r.Interfaces = append(r.Interfaces, ReportInterface{
+		Name:    iface.Name,
+		File:    iface.Path,
+		Line:    iface.Line,
+		Methods: iface.NumMethods(),
+	})
+}
+
+// AddError records err, which occurred generating the mocks of ifaces. A
+// VerifyError is recorded as one error for each type error it holds.
+func (r *Report) AddError(ifaces []*Interface, err error) {
+	var names []string
+	for _, iface := range ifaces {
+		names = append(names, iface.Name)
+	}
+
+	if verr, ok := err.(*VerifyError); ok {
+		for _, terr := range verr.Errors {
+			pos := terr.Fset.Position(terr.Pos)
+			r.Errors = append(r.Errors, ReportError{
+				Interfaces: names,
+				File:       pos.Filename,
+				Line:       pos.Line,
+				Column:     pos.Column,
+				Message:    terr.Msg,
+			})
+		}
+		return
+	}
+
+	rerr := ReportError{Interfaces: names, Message: err.Error()}
+	if len(ifaces) > 0 {
+		rerr.File, rerr.Line = ifaces[0].Path, ifaces[0].Line
+	}
+
+	r.Errors = append(r.Errors, rerr)
+}
+
+// AddFileError records err, which occurred reading or parsing the file or
+// directory at path. Syntax errors are recorded one by one.
+func (r *Report) AddFileError(path string, err error) {
+	if list, ok := err.(scanner.ErrorList); ok {
+		for _, serr := range list {
+			r.Errors = append(r.Errors, ReportError{
+				File:    path,
+				Line:    serr.Pos.Line,
+				Column:  serr.Pos.Column,
+				Message: serr.Msg,
+			})
+		}
+		return
+	}
+
+	r.Errors = append(r.Errors, ReportError{File: path, Message: err.Error()})
+}
+
+// Relative makes the paths in r relative to dir where possible.
+func (r *Report) Relative(dir string) {
+	rel := func(path *string) {
+		if p, err := filepath.Rel(dir, *path); err == nil && filepath.IsAbs(*path) {
+			*path = p
+		}
+	}
+
+	for i := range r.Files {
+		rel(&r.Files[i].Path)
+	}
+	for i := range r.Interfaces {
+		rel(&r.Interfaces[i].File)
+	}
+	for i := range r.Outputs {
+		rel(&r.Outputs[i].Path)
+	}
+	for i := range r.Errors {
+		rel(&r.Errors[i].File)
+	}
+}
+
+// WriteJSON writes r to w as an indented JSON document.
+func (r *Report) WriteJSON(w io.Writer) error {
+	src, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(src, '\n'))
+	return err
+}
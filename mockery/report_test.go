@@ -0,0 +1,104 @@
+package mockery
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportInterface(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	report := NewReport()
+	report.AddInterface(iface)
+	report.Relative(fixturePath)
+
+	assert.Equal(t, []ReportInterface{{Name: "Requester", File: "requester.go", Line: 3, Methods: 1}}, report.Interfaces)
+}
+
+func TestReportError(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.Backend = MustTemplateBackend(`type {{.MockName}} struct{}`)
+	gen.GenerateIPPrologue()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	report := NewReport()
+	report.AddError([]*Interface{iface}, gen.Verify(filepath.Join(fixturePath, "mock_Requester.go"), src))
+	report.AddError([]*Interface{iface}, errors.New("boom"))
+	report.Relative(fixturePath)
+
+	if assert.Len(t, report.Errors, 2) {
+		assert.Equal(t, "mock_Requester.go", report.Errors[0].File)
+		assert.Equal(t, 5, report.Errors[0].Line)
+		assert.Contains(t, report.Errors[0].Message, "missing method Get")
+
+		assert.Equal(t, ReportError{Interfaces: []string{"Requester"}, File: "requester.go", Line: 3, Message: "boom"}, report.Errors[1])
+	}
+}
+
+func TestReportFileError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockery-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bad.go")
+	err = ioutil.WriteFile(path, []byte("package test\n\nvar = 1\n"), 0644)
+	assert.NoError(t, err)
+
+	parser := NewParser()
+	err = parser.Parse(path)
+	assert.Error(t, err)
+
+	report := NewReport()
+	report.AddFileError(path, err)
+	report.AddFileError(dir, errors.New("boom"))
+	report.Relative(dir)
+
+	if assert.Len(t, report.Errors, 2) {
+		assert.Equal(t, "bad.go", report.Errors[0].File)
+		assert.Equal(t, 3, report.Errors[0].Line)
+		assert.Equal(t, 5, report.Errors[0].Column)
+		assert.Empty(t, report.Errors[0].Interfaces)
+
+		assert.Equal(t, ReportError{File: ".", Message: "boom"}, report.Errors[1])
+	}
+}
+
+func TestReportWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := NewReport().WriteJSON(&buf)
+	assert.NoError(t, err)
+
+	expected := `{
+  "files": [],
+  "interfaces": [],
+  "outputs": [],
+  "errors": []
+}
+`
+
+	assert.Equal(t, expected, buf.String())
+}
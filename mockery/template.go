@@ -0,0 +1,338 @@
+package mockery
+
+import (
+	"fmt"
+	"go/ast"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data a TemplateBackend executes its template with.
+type TemplateData struct {
+	// Interface is the name of the mocked interface.
+	Interface string
+
+	// MockName is the name of the type to generate.
+	MockName string
+
+	// Package is the name of the package declaring the interface.
+	Package string
+
+	// InPackage is set if the mock is generated inside that package.
+	InPackage bool
+
+	// ExpandVariadic, Lenient and InOrder mirror the options of the
+	// Generator. With InOrder, the package of the mock imports
+	// InOrderPackage as inorder.
+	ExpandVariadic bool
+	Lenient        bool
+	InOrder        bool
+
+	// Assertion declares that the mock implements the interface, for the
+	// template to end with. It is empty if the mock can not implement it.
+	Assertion string
+
+	// Imports are the imports of the file declaring the interface.
+	Imports []TemplateImport
+
+	Methods []TemplateMethod
+
+	// Skipped are the methods a skip directive leaves out of the mock,
+	// which it still has to declare, such as panicking when called, to
+	// implement the interface.
+	Skipped []TemplateMethod
+}
+
+// TemplateImport is a single import of the file declaring the interface.
+type TemplateImport struct {
+	// Name is the name given to the import, if any, or the one mockery
+	// gives it to tell it from another package of the same name.
+	Name string
+
+	// Path is the unquoted import path.
+	Path string
+}
+
+// TemplateMethod describes a method of the mocked interface.
+type TemplateMethod struct {
+	Name    string
+	Params  TemplateParams
+	Results TemplateParams
+
+	// Variadic is set if the last parameter is variadic.
+	Variadic bool
+}
+
+// TemplateParam describes a parameter or result of a method.
+type TemplateParam struct {
+	// Name is the name of a parameter, which is made up as _aN if the
+	// interface leaves it out. Results have no name.
+	Name string
+
+	// Type is the type as written in the mock, qualified with the package
+	// name if needed. The type of a variadic parameter starts with "...".
+	Type string
+
+	Variadic bool
+
+	// Nillable is set if the type is spelled as a pointer, slice, array,
+	// map, interface, func or channel type. Named types such as error are
+	// not resolved and never nillable.
+	Nillable bool
+}
+
+// TemplateParams is a parameter or result list.
+type TemplateParams []TemplateParam
+
+// Names returns the name of each parameter.
+func (tp TemplateParams) Names() []string {
+	var names []string
+	for _, p := range tp {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// Types returns the type of each parameter.
+func (tp TemplateParams) Types() []string {
+	var types []string
+	for _, p := range tp {
+		types = append(types, p.Type)
+	}
+	return types
+}
+
+// Decls returns the declaration of each parameter, such as "path string".
+func (tp TemplateParams) Decls() []string {
+	var decls []string
+	for _, p := range tp {
+		if p.Name == "" {
+			decls = append(decls, p.Type)
+		} else {
+			decls = append(decls, p.Name+" "+p.Type)
+		}
+	}
+	return decls
+}
+
+// Args returns the arguments passing each parameter on to another call,
+// expanding a variadic parameter with "...".
+func (tp TemplateParams) Args() []string {
+	var args []string
+	for _, p := range tp {
+		if p.Variadic {
+			args = append(args, p.Name+"...")
+		} else {
+			args = append(args, p.Name)
+		}
+	}
+	return args
+}
+
+// Fixed returns the parameters leaving out a variadic last one.
+func (tp TemplateParams) Fixed() TemplateParams {
+	if len(tp) > 0 && tp[len(tp)-1].Variadic {
+		return tp[:len(tp)-1]
+	}
+	return tp
+}
+
+// Last returns the last parameter.
+func (tp TemplateParams) Last() TemplateParam {
+	return tp[len(tp)-1]
+}
+
+// Tuple formats the list as the results of a function signature, wrapping
+// it in parentheses unless it holds a single type.
+func (tp TemplateParams) Tuple() string {
+	decls := tp.Decls()
+	if len(tp) == 1 && tp[0].Name == "" {
+		return decls[0]
+	}
+	if len(tp) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(decls, ", ") + ")"
+}
+
+// TemplateFuncs are the functions available to templates in addition to
+// the text/template builtins.
+var TemplateFuncs = template.FuncMap{
+	"join":         strings.Join,
+	"upperFirst":   upperFirst,
+	"lowerFirst":   lowerFirst,
+	"variadicArgs": variadicArgs,
+}
+
+// TemplateBackend renders mocks by executing a text/template with the
+// TemplateData of the interface.
+type TemplateBackend struct {
+	tmpl    *template.Template
+	imports []string
+}
+
+// ImportsTemplate names the template a template may define to declare the
+// packages its mocks import, as import paths separated by white space. It
+// is executed with a TemplateData holding only the options of the
+// Generator, as the imports do not depend on the interfaces mocked.
+const ImportsTemplate = "imports"
+
+// NewTemplateBackend parses text as a template. The generated mock depends
+// on the packages in imports, in addition to any the template declares in
+// ImportsTemplate.
+func NewTemplateBackend(text string, imports ...string) (*TemplateBackend, error) {
+	tmpl, err := template.New("mock").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	tb := &TemplateBackend{tmpl, imports}
+
+	// Declared imports are listed once up front, so a template that can
+	// not list them fails here rather than for every mock.
+	if _, err := tb.declaredImports(&TemplateData{}); err != nil {
+		return nil, err
+	}
+
+	return tb, nil
+}
+
+// MustTemplateBackend is like NewTemplateBackend but panics if text can not
+// be parsed.
+func MustTemplateBackend(text string, imports ...string) *TemplateBackend {
+	tb, err := NewTemplateBackend(text, imports...)
+	if err != nil {
+		panic(err)
+	}
+
+	return tb
+}
+
+func (tb *TemplateBackend) Imports(g *Generator) []string {
+	declared, err := tb.declaredImports(&TemplateData{
+		InPackage:      g.ip,
+		ExpandVariadic: g.ExpandVariadic,
+		Lenient:        g.Lenient,
+		InOrder:        g.InOrder,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	var imports []string
+	seen := map[string]bool{}
+	for _, path := range append(tb.imports[:len(tb.imports):len(tb.imports)], declared...) {
+		if !seen[path] {
+			seen[path] = true
+			imports = append(imports, path)
+		}
+	}
+
+	return imports
+}
+
+// declaredImports executes ImportsTemplate, if defined, with data and
+// returns the import paths it lists.
+func (tb *TemplateBackend) declaredImports(data *TemplateData) ([]string, error) {
+	tmpl := tb.tmpl.Lookup(ImportsTemplate)
+	if tmpl == nil {
+		return nil, nil
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(buf.String()), nil
+}
+
+func (tb *TemplateBackend) Generate(g *Generator, iface *Interface) error {
+	return tb.tmpl.Execute(&g.buf, g.TemplateData(iface))
+}
+
+// TemplateData returns the data describing the mock of iface, one of the
+// interfaces of g.
+func (g *Generator) TemplateData(iface *Interface) *TemplateData {
+	data := &TemplateData{
+		Interface:      iface.Name,
+		MockName:       g.mockName(iface),
+		Package:        iface.File.Name.Name,
+		InPackage:      g.ip,
+		ExpandVariadic: g.ExpandVariadic,
+		Lenient:        g.Lenient,
+		InOrder:        g.InOrder,
+		Assertion:      g.assertion(iface),
+	}
+
+	for _, imp := range iface.File.Imports {
+		ti := TemplateImport{}
+		ti.Path, _ = strconv.Unquote(imp.Path.Value)
+
+		name := importName(ti.Path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if alias := g.importAlias(iface, name); imp.Name != nil || alias != name {
+			ti.Name = alias
+		}
+		data.Imports = append(data.Imports, ti)
+	}
+
+	for _, method := range g.methods(iface) {
+		data.Methods = append(data.Methods, g.templateMethod(iface, method))
+	}
+	for _, method := range g.skippedMethods(iface) {
+		data.Skipped = append(data.Skipped, g.templateMethod(iface, method))
+	}
+
+	return data
+}
+
+func (g *Generator) templateMethod(iface *Interface, method *ast.Field) TemplateMethod {
+	ftype := method.Type.(*ast.FuncType)
+
+	tm := TemplateMethod{
+		Name:    method.Names[0].Name,
+		Params:  g.templateParams(iface, ftype.Params, true),
+		Results: g.templateParams(iface, ftype.Results, false),
+	}
+	tm.Variadic = len(tm.Params) > 0 && tm.Params.Last().Variadic
+
+	return tm
+}
+
+func (g *Generator) templateParams(iface *Interface, list *ast.FieldList, addNames bool) TemplateParams {
+	var params TemplateParams
+
+	if list == nil {
+		return params
+	}
+
+	for idx, field := range list.List {
+		p := TemplateParam{
+			Type:     g.typeString(iface, field.Type),
+			Nillable: g.isNillable(field.Type),
+		}
+		_, p.Variadic = field.Type.(*ast.Ellipsis)
+
+		if !addNames {
+			params = append(params, p)
+			continue
+		}
+
+		if len(field.Names) == 0 {
+			p.Name = fmt.Sprintf("_a%d", idx)
+			params = append(params, p)
+			continue
+		}
+
+		for _, name := range field.Names {
+			p.Name = name.Name
+			params = append(params, p)
+		}
+	}
+
+	return params
+}
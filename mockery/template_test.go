@@ -0,0 +1,154 @@
+package mockery
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateData(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_ns.go"))
+
+	iface, err := parser.Find("RequesterNS")
+	assert.NoError(t, err)
+
+	data := NewGenerator(iface).TemplateData(iface)
+
+	assert.Equal(t, "RequesterNS", data.Interface)
+	assert.Equal(t, "RequesterNS", data.MockName)
+	assert.Equal(t, "test", data.Package)
+	assert.Equal(t, []TemplateImport{{Path: "net/http"}}, data.Imports)
+	assert.Equal(t, []TemplateMethod{{
+		Name:   "Get",
+		Params: TemplateParams{{Name: "path", Type: "string"}},
+		Results: TemplateParams{
+			{Type: "http.Response"},
+			{Type: "error"},
+		},
+	}}, data.Methods)
+}
+
+func TestTemplateDataVarArg(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(filepath.Join(fixturePath, "requester_vararg_mixed.go"))
+
+	iface, err := parser.Find("RequesterVarArgMixed")
+	assert.NoError(t, err)
+
+	method := NewGenerator(iface).TemplateData(iface).Methods[0]
+
+	assert.True(t, method.Variadic)
+	assert.Equal(t, []string{"prefix string", "paths ...string"}, method.Params.Decls())
+	assert.Equal(t, []string{"prefix", "paths..."}, method.Params.Args())
+	assert.Equal(t, []string{"prefix"}, method.Params.Fixed().Names())
+	assert.Equal(t, "(string, error)", method.Results.Tuple())
+}
+
+func TestTemplateBackend(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	backend, err := NewTemplateBackend(`type {{.MockName}}Stub struct{}
+{{range .Methods}}
+func ({{$.MockName}}Stub) {{.Name}}({{join .Params.Decls ", "}}) {{.Results.Tuple}} {
+	panic("{{lowerFirst $.Interface}}.{{.Name}}")
+}
+{{end}}`)
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.Backend = backend
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `type RequesterStub struct{}
+
+func (RequesterStub) Get(path string) (string, error) {
+	panic("requester.Get")
+}
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+	assert.Empty(t, backend.Imports(gen))
+}
+
+func TestTemplateBackendAssertion(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.Backend = MustTemplateBackend(`type {{.MockName}} struct{}
+{{with .Assertion}}
+{{.}}
+{{end}}`)
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	expected := `type Requester struct{}
+
+var _ test.Requester = (*Requester)(nil)
+`
+
+	assert.Equal(t, expected, gen.buf.String())
+
+	iface.Name = "requester"
+	assert.Empty(t, gen.TemplateData(iface).Assertion)
+}
+
+func TestTemplateBackendParseError(t *testing.T) {
+	_, err := NewTemplateBackend(`{{range .Methods}}`)
+	assert.Error(t, err)
+}
+
+func TestTemplateBackendImports(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	backend, err := NewTemplateBackend(`type {{.MockName}} struct{}
+{{define "imports"}}errors {{if .Lenient}}sync errors{{end}}{{end}}`, "errors", "io")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.Backend = backend
+	assert.Equal(t, []string{"errors", "io"}, backend.Imports(gen))
+
+	gen.Lenient = true
+	assert.Equal(t, []string{"errors", "io", "sync"}, backend.Imports(gen))
+
+	_, err = NewTemplateBackend(`{{define "imports"}}{{.Missing}}{{end}}`)
+	assert.Error(t, err)
+}
+
+func TestDefaultTemplateImports(t *testing.T) {
+	parser := NewParser()
+
+	err := parser.ParseSource("/src/store/store.go", []byte("package store\n\nimport \"sync\"\n\ntype Store interface {\n\tLocker() sync.Locker\n}\n"))
+	assert.NoError(t, err)
+	iface, err := parser.Find("Store")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.ImportPath = "example.com/store"
+	gen.GeneratePrologue("mocks")
+	assert.Contains(t, gen.buf.String(), "import \"github.com/stretchr/testify/mock\"\n\nimport \"sync\"\n")
+
+	gen = NewGenerator(iface)
+	gen.ImportPath = "example.com/store"
+	gen.Lenient = true
+	gen.GeneratePrologue("mocks")
+	assert.Contains(t, gen.buf.String(), "import \"github.com/stretchr/testify/mock\"\nimport \"sync\"\n\n")
+	assert.NotContains(t, gen.buf.String(), "sync2")
+}
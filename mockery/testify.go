@@ -0,0 +1,147 @@
+package mockery
+
+// DefaultTemplate renders mocks embedding testify's mock.Mock. It is used
+// unless another backend is chosen and is a starting point for custom
+// templates.
+const DefaultTemplate = `type {{.MockName}} struct {
+	mock.Mock
+{{- if .Lenient}}
+
+	// Lenient makes calls that match no expectation return zero values
+	// instead of failing.
+	Lenient bool
+
+	fallbackMu sync.Mutex
+{{- end}}
+}
+{{- if .Lenient}}
+
+// expectFallback sets up an expectation returning results for a single
+// call to method with args, unless another expectation matches it. The
+// call then goes through Called like any other, for assertions to see.
+func (m *{{.MockName}}) expectFallback(method string, args []interface{}, results ...interface{}) {
+	m.fallbackMu.Lock()
+	defer m.fallbackMu.Unlock()
+
+	if m.expectsCall(method, args) {
+		return
+	}
+
+	matchers := make([]interface{}, len(args))
+	for i := range matchers {
+		matchers[i] = mock.Anything
+	}
+
+	m.Mock.On(method, matchers...).Return(results...).Once()
+}
+
+// expectsCall reports whether an expectation that is not used up matches
+// a call to method with args.
+func (m *{{.MockName}}) expectsCall(method string, args []interface{}) bool {
+	for _, call := range m.ExpectedCalls {
+		if call.Method != method || call.Repeatability < 0 {
+			continue
+		}
+		if _, diffs := call.Arguments.Diff(args); diffs == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+{{- end}}
+{{range $m := .Methods}}
+{{- $expand := and $.ExpandVariadic .Variadic}}
+{{- $called := join .Params.Names ", "}}
+{{- $any := .Params}}
+{{- if $expand}}{{$called = "_ca..."}}{{$any = .Params.Fixed}}{{end}}
+func (m *{{$.MockName}}) Name_{{.Name}}() string {
+	return "{{.Name}}"
+}
+func (m *{{$.MockName}}) MockOn_{{.Name}}(
+	{{- range $i, $p := .Params}}{{if $i}}, {{end}}{{.Name}} {{if and $expand .Variadic}}...{{end}}interface{}{{end -}}
+) *mock.Call {
+{{- if $expand}}
+{{variadicArgs .Params.Names false}}
+	return m.Mock.On("{{.Name}}", _ca...)
+{{- else}}
+	return m.Mock.On("{{.Name}}"{{range .Params}}, {{.Name}}{{end}})
+{{- end}}
+}
+func (m *{{$.MockName}}) MockOnTyped_{{.Name}}({{join .Params.Decls ", "}}) *mock.Call {
+{{- if $expand}}
+{{variadicArgs .Params.Names true}}
+	return m.Mock.On("{{.Name}}", _ca...)
+{{- else}}
+	return m.Mock.On("{{.Name}}"{{range .Params}}, {{.Name}}{{end}})
+{{- end}}
+}
+func (m *{{$.MockName}}) MockOnAny_{{.Name}}() *mock.Call {
+	return m.Mock.On("{{.Name}}"{{range $any}}, mock.Anything{{end}})
+}
+{{- if $.InOrder}}
+func (m *{{$.MockName}}) MockOnInOrder_{{.Name}}(_seq *inorder.Sequence{{range .Params.Decls}}, {{.}}{{end}}) *mock.Call {
+{{- if $expand}}
+{{variadicArgs .Params.Names true}}
+	return _seq.Add(m.Mock.On("{{.Name}}", _ca...))
+{{- else}}
+	return _seq.Add(m.Mock.On("{{.Name}}"{{range .Params}}, {{.Name}}{{end}}))
+{{- end}}
+}
+{{- end}}
+func (m *{{$.MockName}}) {{.Name}}({{join .Params.Decls ", "}}) {{with .Results.Tuple}}{{.}} {{end}}{
+{{- if $expand}}
+{{variadicArgs .Params.Names true}}
+{{- end}}
+{{- if $.Lenient}}
+	if m.Lenient {
+{{- range $i, $r := .Results}}
+		var r{{$i}} {{.Type}}
+{{- end}}
+		m.expectFallback("{{.Name}}", {{if $expand}}_ca{{else}}[]interface{}{ {{- $called -}} }{{end}}{{range $i, $r := .Results}}, r{{$i}}{{end}})
+	}
+{{end}}
+{{- if .Results}}
+	ret := m.Called({{$called}})
+{{range $i, $r := .Results}}
+	var r{{$i}} {{.Type}}
+	if rf, ok := ret.Get({{$i}}).(func({{join $m.Params.Types ", "}}) {{.Type}}); ok {
+		r{{$i}} = rf({{join $m.Params.Args ", "}})
+	} else {
+{{- if eq .Type "error"}}
+		r{{$i}} = ret.Error({{$i}})
+{{- else if .Nillable}}
+		if ret.Get({{$i}}) != nil {
+			r{{$i}} = ret.Get({{$i}}).({{.Type}})
+		}
+{{- else}}
+		r{{$i}} = ret.Get({{$i}}).({{.Type}})
+{{- end}}
+	}
+{{end}}
+	return {{range $i, $r := .Results}}{{if $i}}, {{end}}r{{$i}}{{end}}
+{{- else}}
+	m.Called({{$called}})
+{{- end}}
+}
+{{- end}}
+{{- range .Skipped}}
+func (m *{{$.MockName}}) {{.Name}}({{join .Params.Decls ", "}}) {{with .Results.Tuple}}{{.}} {{end}}{
+	panic("{{$.MockName}}.{{.Name}} is left out of the mock by a skip directive")
+}
+{{- end}}
+{{- with .Assertion}}
+
+{{.}}
+{{- end}}
+{{define "imports"}}github.com/stretchr/testify/mock
+{{- if .Lenient}}
+sync
+{{- end}}
+{{- end}}`
+
+// InOrderPackage is the package of the sequences taken by the
+// MockOnInOrder_* helpers generated with InOrder set.
+const InOrderPackage = "github.com/ryanbrainard/mockery/inorder"
+
+var testifyBackend = MustTemplateBackend(DefaultTemplate)
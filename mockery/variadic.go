@@ -0,0 +1,21 @@
+package mockery
+
+import "strings"
+
+// variadicArgs returns the statements declaring _ca, holding the fixed
+// parameters in names followed by each element of the variadic parameter
+// that ends names, without a final newline. If the variadic parameter is
+// typed, its elements are copied one by one as the slice can not be
+// converted to []interface{}.
+func variadicArgs(names []string, typed bool) string {
+	fixed, variadic := names[:len(names)-1], names[len(names)-1]
+
+	if !typed {
+		return "\t_ca := append([]interface{}{" + strings.Join(fixed, ", ") + "}, " + variadic + "...)"
+	}
+
+	return "\t_ca := []interface{}{" + strings.Join(fixed, ", ") + "}\n" +
+		"\tfor _, _va := range " + variadic + " {\n" +
+		"\t\t_ca = append(_ca, _va)\n" +
+		"\t}"
+}
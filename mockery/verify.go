@@ -0,0 +1,277 @@
+package mockery
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// sourceImporter type-checks imported packages from source, so mocks can
+// be checked against the interface as it is on disk rather than as last
+// installed. Like packageFiles, it selects files using its context.
+type sourceImporter struct {
+	ctx      *build.Context
+	fset     *token.FileSet
+	packages map[string]*types.Package
+}
+
+// sourceImporters are shared by the Generators with the same context, so
+// packages imported by several mocks are only checked once.
+var sourceImporters = struct {
+	sync.Mutex
+	m map[*build.Context]*sourceImporter
+}{m: map[*build.Context]*sourceImporter{}}
+
+func (g *Generator) importer() *sourceImporter {
+	sourceImporters.Lock()
+	defer sourceImporters.Unlock()
+
+	ctx := g.context()
+	if imp, ok := sourceImporters.m[ctx]; ok {
+		return imp
+	}
+
+	imp := &sourceImporter{ctx, token.NewFileSet(), map[string]*types.Package{}}
+	sourceImporters.m[ctx] = imp
+
+	return imp
+}
+
+func (imp *sourceImporter) Import(path string) (*types.Package, error) {
+	return imp.ImportFrom(path, ".", 0)
+}
+
+func (imp *sourceImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+
+	bp, err := imp.ctx.Import(path, dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if pkg, ok := imp.packages[bp.ImportPath]; ok {
+		if pkg == nil {
+			return nil, fmt.Errorf("import cycle through %s", bp.ImportPath)
+		}
+		return pkg, nil
+	}
+	imp.packages[bp.ImportPath] = nil
+
+	var files []*ast.File
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		f, err := parser.ParseFile(imp.fset, filepath.Join(bp.Dir, name), nil, 0)
+		if err != nil {
+			delete(imp.packages, bp.ImportPath)
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	conf := types.Config{
+		Importer:         imp,
+		Sizes:            types.SizesFor("gc", imp.ctx.GOARCH),
+		IgnoreFuncBodies: true,
+		FakeImportC:      true,
+	}
+
+	pkg, err := conf.Check(bp.ImportPath, imp.fset, files, nil)
+	if err != nil {
+		delete(imp.packages, bp.ImportPath)
+		return nil, err
+	}
+	imp.packages[bp.ImportPath] = pkg
+
+	return pkg, nil
+}
+
+// VerifyError holds the type errors found in a generated mock.
+type VerifyError struct {
+	Errors []types.Error
+}
+
+func (e *VerifyError) Error() string {
+	lines := []string{"generated mock does not compile:"}
+	for _, terr := range e.Errors {
+		lines = append(lines, "\t"+terr.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Verify type-checks src, the formatted mock about to be written to path,
+// together with the rest of the package in that directory. Type errors in
+// the mock, including it not implementing the interface, are returned as
+// a *VerifyError. Errors in the other files of the package are ignored.
+func (g *Generator) Verify(path string, src []byte) error {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, path, src, 0)
+	if err != nil {
+		return err
+	}
+
+	files, err := g.packageFiles(fset, filepath.Dir(path), file)
+	if err != nil {
+		return err
+	}
+
+	assertions, mocks, err := g.assertionFile(fset, path, file)
+	if err != nil {
+		return err
+	}
+	files = append(files, assertions)
+
+	verr := &VerifyError{}
+	conf := types.Config{
+		Importer: g.importer(),
+		Sizes:    types.SizesFor("gc", g.context().GOARCH),
+		Error: func(err error) {
+			terr, ok := err.(types.Error)
+			if !ok {
+				return
+			}
+
+			switch terr.Fset.File(terr.Pos) {
+			case fset.File(file.Pos()):
+				verr.Errors = append(verr.Errors, terr)
+			case fset.File(assertions.Pos()):
+				// Errors in an assertion are told at the mock asserted,
+				// after the import the assertions may start with.
+				decls := assertions.Decls[len(assertions.Decls)-len(mocks):]
+				for idx, decl := range decls {
+					if decl.Pos() <= terr.Pos && terr.Pos < decl.End() {
+						terr.Pos = typeSpec(file, mocks[idx]).Name.Pos()
+					}
+				}
+				verr.Errors = append(verr.Errors, terr)
+			}
+		},
+	}
+	conf.Check(file.Name.Name, fset, files, nil)
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+
+	return nil
+}
+
+// assertionFile parses the assertions of the mocks in file, which
+// templates may leave out, so the mocks are checked to implement their
+// interfaces either way. Templates are free to name their types, so only
+// the mocks file declares are asserted. It returns their names, one for
+// each of the last declarations of the assertions.
+func (g *Generator) assertionFile(fset *token.FileSet, path string, file *ast.File) (*ast.File, []string, error) {
+	var src strings.Builder
+	var mocks []string
+
+	for _, iface := range g.ifaces {
+		if typeSpec(file, g.mockName(iface)) != nil && g.canAssert(iface) {
+			fmt.Fprintf(&src, "\n%s\n", g.assertion(iface))
+			mocks = append(mocks, g.mockName(iface))
+		}
+	}
+
+	header := fmt.Sprintf("package %s\n\n", file.Name.Name)
+	if !g.ip && len(mocks) > 0 {
+		local, err := g.importPath()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		header += fmt.Sprintf("import %q\n", local)
+	}
+
+	name := strings.TrimSuffix(path, ".go") + "_assertions.go"
+	f, err := parser.ParseFile(fset, name, header+src.String(), 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return f, mocks, nil
+}
+
+// packageFiles parses the Go files in dir belonging to the package of
+// file, leaving out files the context of g excludes, and adds file itself.
+// Test files are only included if file is one as well. A file declaring a
+// type named like one of the mocks is an error, as the package would not
+// build with both.
+func (g *Generator) packageFiles(fset *token.FileSet, dir string, file *ast.File) ([]*ast.File, error) {
+	files := []*ast.File{file}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return files, nil
+	}
+
+	test := strings.HasSuffix(fset.File(file.Pos()).Name(), "_test.go")
+
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, ".go") || (!test && strings.HasSuffix(name, "_test.go")) {
+			continue
+		}
+
+		if match, err := g.context().MatchFile(dir, name); err != nil || !match {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if path == fset.File(file.Pos()).Name() {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if f.Name.Name != file.Name.Name {
+			continue
+		}
+
+		for _, name := range g.mockNames() {
+			if typeSpec(f, name) != nil {
+				return nil, fmt.Errorf("%s already declares %s", path, name)
+			}
+		}
+
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// mockNames returns the names of the mocks being generated.
+func (g *Generator) mockNames() []string {
+	var names []string
+	for _, iface := range g.ifaces {
+		names = append(names, MockName(iface, g.ip))
+	}
+
+	return names
+}
+
+// typeSpec returns the declaration of the type called name in f, or nil if
+// f declares none.
+func typeSpec(f *ast.File, name string) *ast.TypeSpec {
+	for _, decl := range f.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+			for _, spec := range gen.Specs {
+				if spec.(*ast.TypeSpec).Name.Name == name {
+					return spec.(*ast.TypeSpec)
+				}
+			}
+		}
+	}
+
+	return nil
+}
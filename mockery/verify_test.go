@@ -0,0 +1,271 @@
+package mockery
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.GenerateIPPrologue()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	err = gen.Verify(filepath.Join(fixturePath, "mock_Requester.go"), src)
+	assert.NoError(t, err)
+}
+
+func TestVerifyInOrder(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.InOrder = true
+	gen.GenerateIPPrologue()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	err = gen.Verify(filepath.Join(fixturePath, "mock_Requester.go"), src)
+	assert.NoError(t, err)
+}
+
+func TestVerifySkipsExcludedFiles(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	// ignored.go redeclares Requester as a struct, which the mock no
+	// longer implements once the file is built.
+	ctx := build.Default
+	ctx.BuildTags = []string{"ignore"}
+
+	gen := NewGenerator(iface)
+	gen.Context = &ctx
+	gen.GenerateIPPrologue()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	err = gen.Verify(filepath.Join(fixturePath, "mock_Requester.go"), src)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "as Requester value")
+	}
+}
+
+func TestVerifyMissingMethod(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.Backend = MustTemplateBackend(`type {{.MockName}} struct{}`)
+	gen.GenerateIPPrologue()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	err = gen.Verify(filepath.Join(fixturePath, "mock_Requester.go"), src)
+	if assert.IsType(t, &VerifyError{}, err) {
+		assert.Len(t, err.(*VerifyError).Errors, 1)
+		assert.Contains(t, err.Error(), "missing method Get")
+	}
+}
+
+func TestVerifyTemplateWithoutAssertion(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.Backend = MustTemplateBackend(`type {{.MockName}} struct{}
+
+func (*{{.MockName}}) Get(path string) (string, error) {
+	return path, nil
+}
+
+type {{.MockName}}Stub struct{}`)
+	gen.GenerateIPPrologue()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	err = gen.Verify(filepath.Join(fixturePath, "mock_Requester.go"), src)
+	assert.NoError(t, err)
+
+	// Mocks named otherwise by the template are not checked.
+	gen = NewGenerator(iface)
+	gen.Backend = MustTemplateBackend(`type {{.MockName}}Stub struct{}`)
+	gen.GenerateIPPrologue()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err = gen.Bytes()
+	assert.NoError(t, err)
+
+	err = gen.Verify(filepath.Join(fixturePath, "mock_Requester.go"), src)
+	assert.NoError(t, err)
+}
+
+func TestVerifyTypeError(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.Backend = MustTemplateBackend(`type {{.MockName}} struct{}
+
+func (*{{.MockName}}) Get(path string) (string, error) {
+	return path, path
+}`)
+	gen.GenerateIPPrologue()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	err = gen.Verify(filepath.Join(fixturePath, "mock_Requester.go"), src)
+	if assert.IsType(t, &VerifyError{}, err) {
+		assert.Contains(t, err.Error(), "mock_Requester.go:8:15: cannot use path")
+	}
+}
+
+func TestVerifyDeclaredMock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockery-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "store.go")
+	err = ioutil.WriteFile(path, []byte("package store\n\ntype Store interface {\n\tGet() string\n}\n"), 0644)
+	assert.NoError(t, err)
+
+	// A mock of Store left by an earlier run under another name.
+	err = ioutil.WriteFile(filepath.Join(dir, "mock_store.go"), []byte("package store\n\ntype MockStore struct{}\n"), 0644)
+	assert.NoError(t, err)
+
+	parser := NewParser()
+	err = parser.Parse(path)
+	assert.NoError(t, err)
+
+	iface, err := parser.Find("Store")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.GenerateIPPrologue()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	err = gen.Verify(filepath.Join(dir, "mock_Store.go"), src)
+	assert.EqualError(t, err, filepath.Join(dir, "mock_store.go")+" already declares MockStore")
+
+	err = gen.Verify(filepath.Join(dir, "mock_store.go"), src)
+	assert.NoError(t, err)
+}
+
+func TestVerifyContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockery-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "store.go")
+	err = ioutil.WriteFile(path, []byte("package store\n\ntype Store interface {\n\tGet() string\n}\n"), 0644)
+	assert.NoError(t, err)
+
+	// Only built on windows, where it would clash with the mock.
+	err = ioutil.WriteFile(filepath.Join(dir, "fake_windows.go"), []byte("package store\n\ntype MockStore struct{}\n"), 0644)
+	assert.NoError(t, err)
+
+	parser := NewParser()
+	err = parser.Parse(path)
+	assert.NoError(t, err)
+
+	iface, err := parser.Find("Store")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.GenerateIPPrologue()
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	ctx := build.Default
+	ctx.GOOS = "linux"
+	gen.Context = &ctx
+
+	err = gen.Verify(filepath.Join(dir, "mock_Store.go"), src)
+	assert.NoError(t, err)
+
+	ctx.GOOS = "windows"
+
+	err = gen.Verify(filepath.Join(dir, "mock_Store.go"), src)
+	assert.EqualError(t, err, filepath.Join(dir, "fake_windows.go")+" already declares MockStore")
+}
+
+func TestMockName(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Requester", MockName(iface, false))
+	assert.Equal(t, "MockRequester", MockName(iface, true))
+
+	iface = &Interface{Name: "requester", Directives: Directives{DirectiveName: "fakeRequester"}}
+	assert.Equal(t, "fakeRequester", MockName(iface, true))
+
+	iface.Directives = nil
+	assert.Equal(t, "mockRequester", MockName(iface, true))
+}
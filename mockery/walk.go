@@ -0,0 +1,440 @@
+package mockery
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SkippedDirs are the names of directories a Walker never descends into,
+// as they hold code that belongs to other projects or is not built.
+var SkippedDirs = map[string]bool{
+	"vendor":       true,
+	"testdata":     true,
+	"node_modules": true,
+}
+
+// Walker finds the interfaces declared in the Go files under a directory,
+// looking only at files the go command would build.
+type Walker struct {
+	// Recursive descends into subdirectories.
+	Recursive bool
+
+	// Filter selects interfaces by name. All are selected if it is nil.
+	Filter *regexp.Regexp
+
+	// Annotated selects the interfaces with a generate directive instead
+	// of using Filter. Interfaces with a skip directive are never selected.
+	Annotated bool
+
+	// LimitOne stops the walk at the first selected interface.
+	LimitOne bool
+
+	// Exclude holds patterns of files and directories to skip. A pattern
+	// enclosed in slashes, like /mock.*/, is a regular expression matching
+	// the whole slash-separated path relative to the walked directory. Any
+	// other pattern is a glob as in .gitignore, matching that path if it
+	// contains a slash and the base name otherwise.
+	Exclude []string
+
+	// SkipDirs are directories not to descend into, such as the one mocks
+	// are written to.
+	SkipDirs []string
+
+	// IncludeTests also searches _test.go files.
+	IncludeTests bool
+
+	// Context selects the files the go command would build for its GOOS,
+	// GOARCH and build tags. It defaults to build.Default.
+	Context *build.Context
+
+	// Skipped, if set, is called with each directory and Go file left out
+	// and the reason why.
+	Skipped func(path, reason string)
+
+	// SkippedInterface, if set, is called with each interface matching
+	// Filter that a skip directive leaves out, from a file that was parsed
+	// nonetheless.
+	SkippedInterface func(iface *Interface, reason string)
+
+	// Parsed, if set, is called with each Go file searched for interfaces.
+	Parsed func(path string)
+
+	// Failed, if set, is called with each directory and Go file that could
+	// not be read or parsed, which are passed to Skipped otherwise.
+	Failed func(path string, err error)
+
+	root     string
+	exclude  []excludePattern
+	skipDirs map[string]bool
+	visited  map[string]bool
+	ifaces   []*Interface
+}
+
+// ignoreRule is a pattern read from a .gitignore file.
+type ignoreRule struct {
+	dir      string
+	re       *regexp.Regexp
+	anchored bool
+	negate   bool
+	dirOnly  bool
+}
+
+// Walk returns the selected interfaces declared under dir. It only fails
+// if an Exclude pattern is invalid or the absolute path of dir or one of
+// SkipDirs can not be found.
+func (w *Walker) Walk(dir string) ([]*Interface, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	w.root = root
+	w.exclude = nil
+	w.skipDirs = map[string]bool{}
+	w.visited = map[string]bool{}
+	w.ifaces = nil
+
+	for _, pattern := range w.Exclude {
+		ep, err := compileExclude(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %s: %s", pattern, err)
+		}
+		w.exclude = append(w.exclude, ep)
+	}
+
+	for _, skip := range w.SkipDirs {
+		abs, err := filepath.Abs(skip)
+		if err != nil {
+			return nil, err
+		}
+		w.skipDirs[abs] = true
+	}
+
+	w.walkDir(w.root, w.readIgnore(w.root, nil))
+
+	return w.ifaces, nil
+}
+
+func (w *Walker) walkDir(dir string, rules []ignoreRule) {
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if w.visited[real] {
+			w.skip(dir, "already searched, the directory links back to itself")
+			return
+		}
+		w.visited[real] = true
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		w.fail(dir, err)
+		return
+	}
+
+	for _, info := range infos {
+		path := filepath.Join(dir, info.Name())
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Stat(path)
+			if err != nil {
+				w.skip(path, "broken symlink")
+				continue
+			}
+			info = target
+		}
+
+		if info.IsDir() {
+			if !w.Recursive {
+				continue
+			}
+
+			if reason := w.skipDir(path, info.Name(), rules); reason != "" {
+				w.skip(path, reason)
+				continue
+			}
+
+			w.walkDir(path, w.readIgnore(path, rules))
+		} else if strings.HasSuffix(info.Name(), ".go") {
+			reason, err := w.skipFile(dir, info.Name(), rules)
+			if err != nil {
+				w.fail(path, err)
+				continue
+			} else if reason != "" {
+				w.skip(path, reason)
+				continue
+			}
+
+			w.parseFile(path)
+		}
+
+		if w.LimitOne && len(w.ifaces) > 0 {
+			return
+		}
+	}
+}
+
+func (w *Walker) skipDir(path, name string, rules []ignoreRule) string {
+	switch {
+	case strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_"):
+		return "hidden directory"
+	case SkippedDirs[name]:
+		return name + " directory"
+	case w.skipDirs[path]:
+		return "output directory"
+	case w.excluded(path):
+		return "excluded"
+	case ignored(path, true, rules):
+		return "ignored by .gitignore"
+	}
+
+	return ""
+}
+
+func (w *Walker) skipFile(dir, name string, rules []ignoreRule) (string, error) {
+	path := filepath.Join(dir, name)
+
+	switch {
+	case strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_"):
+		return "hidden file", nil
+	case w.excluded(path):
+		return "excluded", nil
+	case ignored(path, false, rules):
+		return "ignored by .gitignore", nil
+	case strings.HasSuffix(name, "_test.go") && !w.IncludeTests:
+		return "test file", nil
+	}
+
+	if match, err := w.context().MatchFile(dir, name); err != nil {
+		return "", err
+	} else if !match {
+		return "excluded by build constraints", nil
+	}
+
+	return "", nil
+}
+
+func (w *Walker) context() *build.Context {
+	if w.Context == nil {
+		return &build.Default
+	}
+
+	return w.Context
+}
+
+// parseFile searches the file at path for interfaces, unless it was
+// generated by mockery.
+func (w *Walker) parseFile(path string) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		w.fail(path, err)
+		return
+	} else if IsGenerated(src) {
+		w.skip(path, "generated by mockery")
+		return
+	}
+
+	p := NewParser()
+
+	err = p.ParseSource(path, src)
+	if err != nil {
+		w.fail(path, err)
+		return
+	}
+
+	if w.Parsed != nil {
+		w.Parsed(path)
+	}
+
+	for _, iface := range p.Interfaces() {
+		if w.Annotated {
+			if !iface.Directives.Has(DirectiveGenerate) {
+				continue
+			}
+		} else if w.Filter != nil && !w.Filter.MatchString(iface.Name) {
+			continue
+		}
+
+		if iface.Directives.Has(DirectiveSkip) {
+			if w.SkippedInterface != nil {
+				w.SkippedInterface(iface, "skip directive")
+			}
+			continue
+		}
+
+		w.ifaces = append(w.ifaces, iface)
+		if w.LimitOne {
+			return
+		}
+	}
+}
+
+func (w *Walker) skip(path, reason string) {
+	if w.Skipped != nil {
+		w.Skipped(path, reason)
+	}
+}
+
+func (w *Walker) fail(path string, err error) {
+	if w.Failed != nil {
+		w.Failed(path, err)
+	} else {
+		w.skip(path, err.Error())
+	}
+}
+
+func (w *Walker) excluded(path string) bool {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, ep := range w.exclude {
+		if ep.path && ep.re.MatchString(rel) || !ep.path && ep.re.MatchString(filepath.Base(path)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// excludePattern is a compiled Exclude pattern.
+type excludePattern struct {
+	re *regexp.Regexp
+
+	// path is set if re matches the path relative to the walked directory
+	// rather than the base name.
+	path bool
+}
+
+// compileExclude compiles an Exclude pattern, anchoring regular
+// expressions so they match the whole path.
+func compileExclude(pattern string) (excludePattern, error) {
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile("^(?:" + pattern[1:len(pattern)-1] + ")$")
+		return excludePattern{re, true}, err
+	}
+
+	re, err := regexp.Compile(globRegexp(strings.TrimPrefix(pattern, "/")))
+	return excludePattern{re, strings.Contains(pattern, "/")}, err
+}
+
+// readIgnore adds the rules of the .gitignore file in dir, if any, to
+// rules.
+func (w *Walker) readIgnore(dir string, rules []ignoreRule) []ignoreRule {
+	src, err := ioutil.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return rules
+	}
+
+	// Copy rules, which the caller may extend for a sibling directory.
+	rules = append([]ignoreRule(nil), rules...)
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{dir: dir}
+
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+
+		re, err := regexp.Compile(globRegexp(line))
+		if err != nil {
+			w.skip(filepath.Join(dir, ".gitignore"), "invalid pattern "+line)
+			continue
+		}
+		rule.re = re
+
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// ignored reports whether the last of rules matching path ignores it.
+func ignored(path string, isDir bool, rules []ignoreRule) bool {
+	ignore := false
+
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		name := filepath.Base(path)
+		if rule.anchored {
+			rel, err := filepath.Rel(rule.dir, path)
+			if err != nil {
+				continue
+			}
+			name = filepath.ToSlash(rel)
+		}
+
+		if rule.re.MatchString(name) {
+			ignore = !rule.negate
+		}
+	}
+
+	return ignore
+}
+
+// globRegexp translates a glob as used in .gitignore files into a regular
+// expression. A * or ? never matches a slash, while ** matches across
+// directories.
+func globRegexp(glob string) string {
+	var buf bytes.Buffer
+
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			buf.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			buf.WriteString(".*")
+			i++
+		case c == '*':
+			buf.WriteString("[^/]*")
+		case c == '?':
+			buf.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				buf.WriteString(`\[`)
+				continue
+			}
+
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			buf.WriteString("[" + class + "]")
+			i += end
+		case c == '\\' && i+1 < len(glob):
+			i++
+			buf.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			buf.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+
+	return "^" + buf.String() + "$"
+}
@@ -0,0 +1,308 @@
+package mockery
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTree creates a temporary directory holding files, keyed by their
+// slash-separated path. Go files only need the name of an interface to
+// declare, and get a package clause. Header stands for a generated mock.
+func writeTree(t *testing.T, files map[string]string) string {
+	root, err := ioutil.TempDir("", "mockery-walk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+
+		if content == Header {
+			content = Header + "\n\npackage mocks\n\ntype Generated interface{}\n"
+		} else if filepath.Ext(name) == ".go" {
+			content = "package " + filepath.Base(filepath.Dir(path)) + "\n\ntype " + content + " interface{}\n"
+		}
+
+		os.MkdirAll(filepath.Dir(path), 0755)
+		err := ioutil.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func walkNames(t *testing.T, w *Walker, root string) []string {
+	ifaces, err := w.Walk(root)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, iface := range ifaces {
+		names = append(names, iface.Name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func TestWalkerSkipsDirs(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a/a.go":            "A",
+		"a/a_test.go":       "ATest",
+		"a/_b.go":           "Underscore",
+		"vendor/v/v.go":     "Vendored",
+		"a/testdata/td.go":  "TestData",
+		".hidden/h.go":      "Hidden",
+		"mocks/A.go":        "Old",
+		"a/mocks/Gen.go":    Header,
+		"node_modules/n.go": "Node",
+	})
+	defer os.RemoveAll(root)
+
+	var skipped []string
+	w := &Walker{
+		Recursive: true,
+		SkipDirs:  []string{filepath.Join(root, "mocks")},
+		Skipped: func(path, reason string) {
+			rel, _ := filepath.Rel(root, path)
+			skipped = append(skipped, filepath.ToSlash(rel)+": "+reason)
+		},
+	}
+
+	assert.Equal(t, []string{"A"}, walkNames(t, w, root))
+
+	assert.Contains(t, skipped, "a/a_test.go: test file")
+	assert.Contains(t, skipped, "a/mocks/Gen.go: generated by mockery")
+	assert.Contains(t, skipped, "mocks: output directory")
+	assert.Contains(t, skipped, "vendor: vendor directory")
+	assert.Contains(t, skipped, "a/testdata: testdata directory")
+
+	w.IncludeTests = true
+	assert.Equal(t, []string{"A", "ATest"}, walkNames(t, w, root))
+}
+
+func TestWalkerExclude(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a/a.go":       "A",
+		"a/a_gen.go":   "AGen",
+		"b/b.go":       "B",
+		"b/sub/s.go":   "BSub",
+		"c/sub/sub.go": "Sub",
+	})
+	defer os.RemoveAll(root)
+
+	cases := []struct {
+		exclude []string
+		names   []string
+	}{
+		{[]string{"*_gen.go", "/c/"}, []string{"A", "B", "BSub"}},
+		// Globs with a slash match the path, others the base name.
+		{[]string{"b/*.go"}, []string{"A", "AGen", "BSub", "Sub"}},
+		{[]string{"sub"}, []string{"A", "AGen", "B"}},
+		{[]string{"/b"}, []string{"A", "AGen", "Sub"}},
+		// Regular expressions match the whole path.
+		{[]string{"/sub/"}, []string{"A", "AGen", "B", "BSub", "Sub"}},
+		{[]string{"/c/sub/"}, []string{"A", "AGen", "B", "BSub"}},
+		{[]string{"/.*_gen\\.go/"}, []string{"A", "B", "BSub", "Sub"}},
+	}
+
+	for _, c := range cases {
+		w := &Walker{Recursive: true, Exclude: c.exclude}
+		assert.Equal(t, c.names, walkNames(t, w, root), "%v", c.exclude)
+	}
+
+	w := &Walker{Exclude: []string{"/(/"}}
+	_, err := w.Walk(root)
+	assert.EqualError(t, err, "invalid exclude pattern /(/: error parsing regexp: missing closing ): `^(?:()$`")
+}
+
+func TestWalkerGitignore(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".gitignore":     "gen/\n*_skip.go\n!keep_skip.go\n",
+		"a/a.go":         "A",
+		"a/a_skip.go":    "ASkip",
+		"a/keep_skip.go": "Keep",
+		"gen/gen.go":     "Gen",
+		"b/.gitignore":   "/b.go\n",
+		"b/b.go":         "B",
+		"b/c/b.go":       "C",
+	})
+	defer os.RemoveAll(root)
+
+	w := &Walker{Recursive: true}
+	assert.Equal(t, []string{"A", "C", "Keep"}, walkNames(t, w, root))
+}
+
+func TestWalkerSymlinkLoop(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a/a.go": "A",
+	})
+	defer os.RemoveAll(root)
+
+	err := os.Symlink("..", filepath.Join(root, "a", "loop"))
+	if err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	var skipped []string
+	w := &Walker{
+		Recursive: true,
+		Skipped: func(path, reason string) {
+			skipped = append(skipped, filepath.Base(path))
+		},
+	}
+
+	assert.Equal(t, []string{"A"}, walkNames(t, w, root))
+	assert.Equal(t, []string{"loop"}, skipped)
+}
+
+func TestWalkerFilter(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a/a.go": "A",
+		"b/b.go": "B",
+	})
+	defer os.RemoveAll(root)
+
+	w := &Walker{Recursive: true, Filter: regexp.MustCompile("^B$")}
+	assert.Equal(t, []string{"B"}, walkNames(t, w, root))
+
+	w = &Walker{Recursive: true, LimitOne: true}
+	assert.Len(t, walkNames(t, w, root), 1)
+
+	w = &Walker{}
+	assert.Empty(t, walkNames(t, w, root))
+}
+
+func TestWalkerFailed(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a/a.go":   "A",
+		"a/bad.go": "(",
+	})
+	defer os.RemoveAll(root)
+
+	var skipped []string
+	w := &Walker{
+		Recursive: true,
+		Skipped: func(path, reason string) {
+			skipped = append(skipped, filepath.Base(path))
+		},
+	}
+
+	assert.Equal(t, []string{"A"}, walkNames(t, w, root))
+	assert.Equal(t, []string{"bad.go"}, skipped)
+
+	var failed []string
+	skipped = nil
+	w.Failed = func(path string, err error) {
+		failed = append(failed, filepath.Base(path))
+	}
+
+	assert.Equal(t, []string{"A"}, walkNames(t, w, root))
+	assert.Equal(t, []string{"bad.go"}, failed)
+	assert.Empty(t, skipped)
+}
+
+func TestWalkerContext(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a/a.go":             "A",
+		"a/store_linux.go":   "Linux",
+		"a/store_windows.go": "Windows",
+		"a/store_arm64.go":   "Arm64",
+	})
+	defer os.RemoveAll(root)
+
+	err := ioutil.WriteFile(filepath.Join(root, "a", "tagged.go"), []byte("//go:build integration && !windows\n\npackage a\n\ntype Tagged interface{}\n"), 0644)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		goos, goarch string
+		tags         []string
+		names        []string
+	}{
+		{"linux", "amd64", nil, []string{"A", "Linux"}},
+		{"linux", "amd64", []string{"integration"}, []string{"A", "Linux", "Tagged"}},
+		{"windows", "arm64", []string{"integration"}, []string{"A", "Arm64", "Windows"}},
+		{"darwin", "arm64", []string{"integration"}, []string{"A", "Arm64", "Tagged"}},
+	}
+
+	for _, c := range cases {
+		ctx := build.Default
+		ctx.GOOS, ctx.GOARCH, ctx.BuildTags = c.goos, c.goarch, c.tags
+
+		w := &Walker{Recursive: true, Context: &ctx}
+		assert.Equal(t, c.names, walkNames(t, w, root), "%s/%s %v", c.goos, c.goarch, c.tags)
+	}
+}
+
+func TestWalkerDirectives(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a/a.go": "A",
+	})
+	defer os.RemoveAll(root)
+
+	err := ioutil.WriteFile(filepath.Join(root, "a", "b.go"), []byte(`package a
+
+//mockery:generate
+type B interface{}
+
+//mockery:skip
+type C interface{}
+`), 0644)
+	assert.NoError(t, err)
+
+	var skipped, skippedIfaces, parsed []string
+	w := &Walker{
+		Recursive: true,
+		Skipped: func(path, reason string) {
+			skipped = append(skipped, path)
+		},
+		SkippedInterface: func(iface *Interface, reason string) {
+			skippedIfaces = append(skippedIfaces, iface.Name+": "+reason)
+		},
+		Parsed: func(path string) {
+			parsed = append(parsed, filepath.Base(path))
+		},
+	}
+	assert.Equal(t, []string{"A", "B"}, walkNames(t, w, root))
+
+	// b.go is parsed, so it is not skipped even though C is.
+	assert.Empty(t, skipped)
+	assert.Equal(t, []string{"C: skip directive"}, skippedIfaces)
+	assert.Equal(t, []string{"a.go", "b.go"}, parsed)
+
+	w.Annotated = true
+	assert.Equal(t, []string{"B"}, walkNames(t, w, root))
+}
+
+func TestGlobRegexp(t *testing.T) {
+	cases := []struct {
+		glob  string
+		match []string
+		miss  []string
+	}{
+		{"*.go", []string{"a.go"}, []string{"a/b.go", "a.gox"}},
+		{"a?c", []string{"abc"}, []string{"ac", "a/c"}},
+		{"**/mocks", []string{"mocks", "a/b/mocks"}, []string{"mocksx"}},
+		{"a/**", []string{"a/b", "a/b/c"}, []string{"b/a"}},
+		{"[!a]b", []string{"bb"}, []string{"ab"}},
+		{"a.b", []string{"a.b"}, []string{"axb"}},
+	}
+
+	for _, c := range cases {
+		re := regexp.MustCompile(globRegexp(c.glob))
+		for _, s := range c.match {
+			assert.True(t, re.MatchString(s), "%s should match %s", c.glob, s)
+		}
+		for _, s := range c.miss {
+			assert.False(t, re.MatchString(s), "%s should not match %s", c.glob, s)
+		}
+	}
+}
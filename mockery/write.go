@@ -0,0 +1,76 @@
+package mockery
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vektra/errors"
+)
+
+// Header marks files generated by mockery. Bytes puts it at the top of
+// every file, following the convention for generated Go code.
+const Header = "// Code generated by mockery. DO NOT EDIT."
+
+var ErrNotGenerated = errors.New("file was not generated by mockery")
+
+// IsGenerated reports whether src carries the Header before its package
+// clause.
+func IsGenerated(src []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == Header {
+			return true
+		}
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+	}
+
+	return false
+}
+
+// WriteFile writes src to path by way of a temporary file in the same
+// directory, so a failure never leaves path truncated or half written.
+// Unless force is set, an existing file is only replaced if IsGenerated
+// holds for it and ErrNotGenerated is returned otherwise. A replaced file
+// keeps its permissions, and a new one is created with 0644.
+func WriteFile(path string, src []byte, force bool) error {
+	if !force {
+		existing, err := ioutil.ReadFile(path)
+		if err == nil && !IsGenerated(existing) {
+			return ErrNotGenerated
+		}
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".mockery-")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(src)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
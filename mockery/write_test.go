@@ -0,0 +1,104 @@
+package mockery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGenerated(t *testing.T) {
+	assert.True(t, IsGenerated([]byte(Header+"\n\npackage mocks\n")))
+	assert.True(t, IsGenerated([]byte("\n// note\n\n"+Header+"\npackage mocks\n")))
+	assert.False(t, IsGenerated([]byte("package mocks\n\n"+Header+"\n")))
+	assert.False(t, IsGenerated([]byte("// Code generated by hand.\npackage mocks\n")))
+}
+
+func TestBytesHeader(t *testing.T) {
+	parser := NewParser()
+	parser.Parse(testFile)
+
+	iface, err := parser.Find("Requester")
+	assert.NoError(t, err)
+
+	gen := NewGenerator(iface)
+	gen.GeneratePrologue("mocks")
+
+	err = gen.Generate()
+	assert.NoError(t, err)
+
+	src, err := gen.Bytes()
+	assert.NoError(t, err)
+
+	assert.True(t, IsGenerated(src))
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockery")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "Requester.go")
+	generated := []byte(Header + "\n\npackage mocks\n")
+
+	err = WriteFile(path, generated, false)
+	assert.NoError(t, err)
+
+	err = WriteFile(path, append(generated, "\nvar x int\n"...), false)
+	assert.NoError(t, err)
+
+	src, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), "var x int")
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	infos, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, infos, 1)
+}
+
+func TestWriteFileHandWritten(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockery")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "Requester.go")
+	handWritten := []byte("package mocks\n\ntype Requester struct{}\n")
+
+	err = ioutil.WriteFile(path, handWritten, 0644)
+	assert.NoError(t, err)
+
+	err = WriteFile(path, []byte(Header+"\n\npackage mocks\n"), false)
+	assert.Equal(t, ErrNotGenerated, err)
+
+	src, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, handWritten, src)
+
+	err = WriteFile(path, []byte(Header+"\n\npackage mocks\n"), true)
+	assert.NoError(t, err)
+}
+
+func TestWriteFileKeepsMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockery")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "store.go")
+
+	err = ioutil.WriteFile(path, []byte("package store\n"), 0600)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chmod(path, 0600))
+
+	err = WriteFile(path, []byte("package store\n\n//go:generate mockery\n"), true)
+	assert.NoError(t, err)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
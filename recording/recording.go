@@ -0,0 +1,404 @@
+// Package recording captures the calls passed through the recorders mockery
+// generates with -backend recorder, and replays them as expectations on the
+// testify mocks it generates.
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// Call is a call passed through a recorder, with its arguments and results
+// encoded as JSON. Errors are encoded as their message, and values JSON can
+// not encode, such as functions and channels, as null.
+type Call struct {
+	Interface string            `json:"interface"`
+	Method    string            `json:"method"`
+	Args      []json.RawMessage `json:"args"`
+	Results   []json.RawMessage `json:"results"`
+	Duration  time.Duration     `json:"duration"`
+}
+
+// Sink receives the calls recorded by recorders.
+type Sink interface {
+	Record(call Call)
+}
+
+// JSONSink writes each call it receives to a writer as a line of JSON.
+type JSONSink struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewJSONSink returns a JSONSink writing to w.
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{w: w}
+}
+
+func (s *JSONSink) Record(call Call) {
+	src, err := json.Marshal(call)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return
+	}
+	if err == nil {
+		_, err = s.w.Write(append(src, '\n'))
+	}
+	s.err = err
+}
+
+// Err returns the first error writing a call, after which calls are no
+// longer written.
+func (s *JSONSink) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// DefaultPath is the file DefaultSink appends calls to. It is taken from
+// $MOCKERY_RECORDING if set.
+var DefaultPath = "recording.jsonl"
+
+func init() {
+	if path := os.Getenv("MOCKERY_RECORDING"); path != "" {
+		DefaultPath = path
+	}
+}
+
+var defaultSink struct {
+	once sync.Once
+	sink *JSONSink
+}
+
+// DefaultSink returns the sink recorders without one record to, appending
+// calls to DefaultPath as JSON lines. The file is opened on first use.
+func DefaultSink() *JSONSink {
+	defaultSink.once.Do(func() {
+		f, err := os.OpenFile(DefaultPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			defaultSink.sink = &JSONSink{err: err}
+			return
+		}
+
+		defaultSink.sink = NewJSONSink(f)
+	})
+
+	return defaultSink.sink
+}
+
+// Record records a call to method of the interface named iface, which took
+// d, with args and results to sink, or to DefaultSink if sink is nil.
+func Record(sink Sink, iface, method string, d time.Duration, args, results []interface{}) {
+	if sink == nil {
+		sink = DefaultSink()
+	}
+
+	sink.Record(Call{
+		Interface: iface,
+		Method:    method,
+		Args:      encode(args),
+		Results:   encode(results),
+		Duration:  d,
+	})
+}
+
+func encode(values []interface{}) []json.RawMessage {
+	raws := []json.RawMessage{}
+
+	for _, v := range values {
+		if err, ok := v.(error); ok && !isNil(v) {
+			v = err.Error()
+		}
+
+		src, err := json.Marshal(v)
+		if err != nil {
+			src = []byte("null")
+		}
+
+		raws = append(raws, src)
+	}
+
+	return raws
+}
+
+// isNil reports whether v holds a nil pointer or the like, whose methods
+// may not be callable.
+func isNil(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+
+	return false
+}
+
+// Load reads the calls written to r by a JSONSink.
+func Load(r io.Reader) ([]Call, error) {
+	var calls []Call
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var call Call
+		err := json.Unmarshal(scanner.Bytes(), &call)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+
+		calls = append(calls, call)
+	}
+
+	return calls, scanner.Err()
+}
+
+// LoadFile reads the calls recorded to the file at path.
+func LoadFile(path string) ([]Call, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Select returns the calls to the interface named iface, as recordings
+// to DefaultSink can hold the calls of several recorders.
+func Select(calls []Call, iface string) []Call {
+	var selected []Call
+	for _, call := range calls {
+		if call.Interface == iface {
+			selected = append(selected, call)
+		}
+	}
+
+	return selected
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Replay sets up an expectation on mock, a testify mock generated by
+// mockery, for each of calls. Each is made with the MockOnTyped_ method for
+// the method called, and returns the recorded results once, so calls with
+// the same arguments return their results in the order recorded.
+//
+// Arguments and results are decoded into the types of the method. Errors
+// are replayed as errors holding the recorded message. Arguments that can
+// not be decoded into what was passed, as they were recorded as null, are
+// of an interface type or do not survive JSON unchanged, match any value,
+// which the typed helper can not be given, so calls passing them are
+// replayed with the MockOn_ method instead. Results recorded as null or of
+// an interface type other than error are replayed as zero values.
+func Replay(mock interface{}, calls []Call) error {
+	v := reflect.ValueOf(mock)
+
+	for idx, call := range calls {
+		method := v.MethodByName(call.Method)
+		if !method.IsValid() {
+			return fmt.Errorf("call %d: %T has no method %s", idx+1, mock, call.Method)
+		}
+
+		args, typed, err := decodeArgs(call.Args, method.Type(), false)
+		if err != nil {
+			return fmt.Errorf("call %d to %s: argument %s", idx+1, call.Method, err)
+		}
+
+		on := v.MethodByName("MockOnTyped_" + call.Method)
+		if !typed || !on.IsValid() {
+			on = v.MethodByName("MockOn_" + call.Method)
+			if !on.IsValid() {
+				return fmt.Errorf("call %d: %T has no method MockOn_%s", idx+1, mock, call.Method)
+			}
+
+			args, _, err = decodeArgs(call.Args, method.Type(), on.Type().IsVariadic())
+			if err != nil {
+				return fmt.Errorf("call %d to %s: argument %s", idx+1, call.Method, err)
+			}
+		}
+
+		results, err := decodeValues(call.Results, method.Type().NumOut(), method.Type().Out)
+		if err != nil {
+			return fmt.Errorf("call %d to %s: result %s", idx+1, call.Method, err)
+		}
+
+		var expectation []reflect.Value
+		if on.Type().IsVariadic() {
+			expectation = on.CallSlice(args)
+		} else {
+			expectation = on.Call(args)
+		}
+
+		ret := expectation[0].MethodByName("Return")
+		if !ret.IsValid() {
+			return fmt.Errorf("call %d: %T is not a testify mock", idx+1, mock)
+		}
+
+		ret.Call(results)[0].MethodByName("Once").Call(nil)
+	}
+
+	return nil
+}
+
+// decodeArgs decodes raws into the parameters of method, to be passed to
+// its MockOnTyped_ or MockOn_ method, and reports whether every argument
+// was decoded rather than matching any value. If expand is set, the MockOn_
+// method takes the variadic arguments one by one, so they are decoded one
+// by one as well.
+func decodeArgs(raws []json.RawMessage, method reflect.Type, expand bool) (args []reflect.Value, typed bool, err error) {
+	n := method.NumIn()
+	if len(raws) != n {
+		return nil, false, fmt.Errorf("count: recorded %d, expected %d", len(raws), n)
+	}
+
+	typed = true
+	for idx, raw := range raws {
+		if idx == n-1 && method.IsVariadic() && expand {
+			var elems []json.RawMessage
+			err := json.Unmarshal(raw, &elems)
+			if err != nil {
+				return nil, false, fmt.Errorf("%d: %s", idx+1, err)
+			}
+
+			rest := []interface{}{}
+			for _, elem := range elems {
+				arg := decodeArg(elem, method.In(idx).Elem())
+				typed = typed && arg != mock.Anything
+				rest = append(rest, arg)
+			}
+
+			args = append(args, reflect.ValueOf(rest))
+			break
+		}
+
+		arg := decodeArg(raw, method.In(idx))
+		typed = typed && arg != mock.Anything
+		args = append(args, reflect.ValueOf(arg))
+	}
+
+	return args, typed, nil
+}
+
+// decodeArg decodes raw into a value of type t, or returns mock.Anything
+// if it can not be decoded into the value recorded.
+func decodeArg(raw json.RawMessage, t reflect.Type) interface{} {
+	if string(raw) == "null" || !encodable(t, map[reflect.Type]bool{}) {
+		return mock.Anything
+	}
+
+	ptr := reflect.New(t)
+	err := json.Unmarshal(raw, ptr.Interface())
+	if err != nil {
+		return mock.Anything
+	}
+
+	// Values encoding themselves may lose something to JSON, which shows
+	// in them encoding differently once decoded.
+	var want bytes.Buffer
+	src, err := json.Marshal(ptr.Interface())
+	if err != nil || json.Compact(&want, raw) != nil || !bytes.Equal(src, want.Bytes()) {
+		return mock.Anything
+	}
+
+	return ptr.Elem().Interface()
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// encodable reports whether values of type t encode all they hold to JSON,
+// rather than the fields JSON skips or the interfaces it can not decode.
+// Types encoding themselves are trusted to.
+func encodable(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] || t.Implements(marshalerType) || t.Implements(textMarshalerType) {
+		return true
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return false
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return encodable(t.Elem(), seen)
+	case reflect.Map:
+		return encodable(t.Key(), seen) && encodable(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" || field.Tag.Get("json") == "-" || !encodable(field.Type, seen) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// decodeValues decodes raws into the n types given by typ.
+func decodeValues(raws []json.RawMessage, n int, typ func(int) reflect.Type) ([]reflect.Value, error) {
+	if len(raws) != n {
+		return nil, fmt.Errorf("count: recorded %d, expected %d", len(raws), n)
+	}
+
+	var values []reflect.Value
+	for idx, raw := range raws {
+		value, err := decode(raw, typ(idx))
+		if err != nil {
+			return nil, fmt.Errorf("%d: %s", idx+1, err)
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func decode(raw json.RawMessage, t reflect.Type) (reflect.Value, error) {
+	// Only the message of errors is recorded, and nothing of the concrete
+	// types of other interface values.
+	if string(raw) == "null" || t.Kind() == reflect.Interface && t != errorType && t.NumMethod() > 0 {
+		return reflect.Zero(t), nil
+	}
+
+	if t == errorType {
+		var msg string
+		err := json.Unmarshal(raw, &msg)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(errors.New(msg)), nil
+	}
+
+	ptr := reflect.New(t)
+	err := json.Unmarshal(raw, ptr.Interface())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return ptr.Elem(), nil
+}
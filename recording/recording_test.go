@@ -0,0 +1,226 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Requester is shaped like a testify mock generated by mockery.
+type Requester struct {
+	mock.Mock
+}
+
+func (m *Requester) MockOn_Get(path interface{}) *mock.Call {
+	return m.Mock.On("Get", path)
+}
+func (m *Requester) MockOnTyped_Get(path string) *mock.Call {
+	return m.Mock.On("Get", path)
+}
+func (m *Requester) Get(path string) (string, error) {
+	ret := m.Called(path)
+
+	return ret.String(0), ret.Error(1)
+}
+
+func (m *Requester) MockOn_Find(limit interface{}, paths interface{}) *mock.Call {
+	return m.Mock.On("Find", limit, paths)
+}
+func (m *Requester) MockOnTyped_Find(limit int, paths ...string) *mock.Call {
+	return m.Mock.On("Find", limit, paths)
+}
+func (m *Requester) Find(limit int, paths ...string) []string {
+	ret := m.Called(limit, paths)
+
+	var r0 []string
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]string)
+	}
+
+	return r0
+}
+
+func (m *Requester) MockOn_Open(ctx interface{}, path interface{}, flags ...interface{}) *mock.Call {
+	_ca := append([]interface{}{ctx, path}, flags...)
+	return m.Mock.On("Open", _ca...)
+}
+func (m *Requester) MockOnTyped_Open(ctx context.Context, path string, flags ...int) *mock.Call {
+	_ca := []interface{}{ctx, path}
+	for _, _va := range flags {
+		_ca = append(_ca, _va)
+	}
+	return m.Mock.On("Open", _ca...)
+}
+func (m *Requester) Open(ctx context.Context, path string, flags ...int) (io.ReadCloser, error) {
+	_ca := []interface{}{ctx, path}
+	for _, _va := range flags {
+		_ca = append(_ca, _va)
+	}
+	ret := m.Called(_ca...)
+
+	var r0 io.ReadCloser
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).(io.ReadCloser)
+	}
+
+	return r0, ret.Error(1)
+}
+
+func TestRecordJSON(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONSink(&buf)
+
+	Record(sink, "Requester", "Get", time.Millisecond, []interface{}{"/a"}, []interface{}{"", errors.New("boom")})
+	Record(sink, "Requester", "Watch", 0, []interface{}{func() {}}, []interface{}{nil})
+	assert.NoError(t, sink.Err())
+
+	expected := `{"interface":"Requester","method":"Get","args":["/a"],"results":["","boom"],"duration":1000000}
+{"interface":"Requester","method":"Watch","args":[null],"results":[null],"duration":0}
+`
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestReplay(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONSink(&buf)
+
+	Record(sink, "Requester", "Get", 0, []interface{}{"/a"}, []interface{}{"first", nil})
+	Record(sink, "Requester", "Get", 0, []interface{}{"/a"}, []interface{}{"", errors.New("boom")})
+	Record(sink, "Requester", "Find", 0, []interface{}{2, []string{"x", "y"}}, []interface{}{[]string{"x"}})
+
+	Record(sink, "Other", "Get", 0, []interface{}{"/b"}, []interface{}{"other", nil})
+
+	calls, err := Load(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, calls, 4)
+
+	calls = Select(calls, "Requester")
+	assert.Len(t, calls, 3)
+
+	m := &Requester{}
+	err = Replay(m, calls)
+	assert.NoError(t, err)
+
+	s, err := m.Get("/a")
+	assert.Equal(t, "first", s)
+	assert.NoError(t, err)
+
+	s, err = m.Get("/a")
+	assert.Equal(t, "", s)
+	assert.EqualError(t, err, "boom")
+
+	assert.Equal(t, []string{"x"}, m.Find(2, "x", "y"))
+
+	m.AssertExpectations(t)
+}
+
+// TypedRequester only has the typed helpers of a testify mock.
+type TypedRequester struct {
+	mock.Mock
+}
+
+func (m *TypedRequester) MockOnTyped_Get(path string) *mock.Call {
+	return m.Mock.On("Get", path)
+}
+func (m *TypedRequester) Get(path string) (string, error) {
+	ret := m.Called(path)
+
+	return ret.String(0), ret.Error(1)
+}
+
+func TestReplayTyped(t *testing.T) {
+	calls, err := Load(strings.NewReader(`{"method":"Get","args":["/a"],"results":["a",null]}` + "\n"))
+	assert.NoError(t, err)
+
+	m := &TypedRequester{}
+	err = Replay(m, calls)
+	assert.NoError(t, err)
+
+	s, err := m.Get("/a")
+	assert.Equal(t, "a", s)
+	assert.NoError(t, err)
+
+	m.AssertExpectations(t)
+
+	// An argument recorded as null matches any value, which only the
+	// untyped helper can be given.
+	calls, err = Load(strings.NewReader(`{"method":"Get","args":[null],"results":["a",null]}` + "\n"))
+	assert.NoError(t, err)
+
+	err = Replay(&TypedRequester{}, calls)
+	assert.EqualError(t, err, "call 1: *recording.TypedRequester has no method MockOn_Get")
+}
+
+func TestReplayAnything(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONSink(&buf)
+
+	type key struct{ name string }
+	ctx := context.WithValue(context.Background(), key{"user"}, "me")
+
+	Record(sink, "Requester", "Open", 0, []interface{}{ctx, "/a", []int{1, 2}}, []interface{}{io.NopCloser(nil), nil})
+	Record(sink, "Requester", "Open", 0, []interface{}{nil, "/b", []int(nil)}, []interface{}{nil, errors.New("boom")})
+
+	calls, err := Load(&buf)
+	assert.NoError(t, err)
+
+	m := &Requester{}
+	err = Replay(m, calls)
+	assert.NoError(t, err)
+
+	// The context is an interface and the result too, so the first call
+	// matches any context and returns nil.
+	r, err := m.Open(context.TODO(), "/a", 1, 2)
+	assert.Nil(t, r)
+	assert.NoError(t, err)
+
+	_, err = m.Open(ctx, "/b")
+	assert.EqualError(t, err, "boom")
+
+	m.AssertExpectations(t)
+	assert.Equal(t, mock.Arguments{mock.Anything, "/a", 1, 2}, m.ExpectedCalls[0].Arguments)
+}
+
+func TestDecodeArg(t *testing.T) {
+	type exported struct {
+		Name string
+	}
+	type unexported struct {
+		name string
+	}
+
+	assert.Equal(t, exported{"x"}, decodeArg(json.RawMessage(`{"Name":"x"}`), reflect.TypeOf(exported{})))
+	assert.Equal(t, mock.Anything, decodeArg(json.RawMessage(`{}`), reflect.TypeOf(unexported{})))
+	assert.Equal(t, mock.Anything, decodeArg(json.RawMessage(`[{}]`), reflect.TypeOf([]*unexported{})))
+	assert.Equal(t, mock.Anything, decodeArg(json.RawMessage(`"x"`), reflect.TypeOf((*interface{})(nil)).Elem()))
+	assert.Equal(t, mock.Anything, decodeArg(json.RawMessage(`1`), reflect.TypeOf("")))
+	assert.Equal(t, mock.Anything, decodeArg(json.RawMessage(`null`), reflect.TypeOf([]int{})))
+	assert.Equal(t, 1.5, decodeArg(json.RawMessage(`1.5`), reflect.TypeOf(0.0)))
+}
+
+func TestReplayErrors(t *testing.T) {
+	calls, err := Load(strings.NewReader(`{"method":"Put","args":[],"results":[]}` + "\n"))
+	assert.NoError(t, err)
+
+	err = Replay(&Requester{}, calls)
+	assert.EqualError(t, err, "call 1: *recording.Requester has no method Put")
+
+	calls, err = Load(strings.NewReader(`{"method":"Get","args":[],"results":["",null]}` + "\n"))
+	assert.NoError(t, err)
+
+	err = Replay(&Requester{}, calls)
+	assert.Error(t, err)
+
+	_, err = Load(strings.NewReader("{\n"))
+	assert.Error(t, err)
+}